@@ -0,0 +1,114 @@
+package mermaidgen
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Diagram is the interface shared by all diagram types of this module, e.g.
+// flowchart.Flowchart and gantt.Gantt.
+type Diagram interface {
+	fmt.Stringer
+	io.WriterTo
+	LiveURL() (url string)
+	ViewInBrowser() (err error)
+}
+
+// MermaidCLI is the mermaid command line interface (mermaid-cli) used by
+// WriteZip to render SVGs. If it can't be found in PATH, no SVGs are
+// rendered. Set it to an empty string to disable SVGs.
+var MermaidCLI = "mmdc"
+
+// WriteZip writes a zip archive to w that contains each Diagram's mermaid code
+// as a .mmd file named after its key, in the order of the sorted keys. The
+// .mmd extension is only appended if the key doesn't end with it already,
+// keys resulting in the same file name (like "x" and "x.mmd") are rejected
+// with an error before anything is written. If MermaidCLI is available, an
+// .svg file rendered from the code is added behind each .mmd file. The first
+// error is returned with the name of the offending entry, the archive is
+// incomplete in that case.
+func WriteZip(w io.Writer, entries map[string]Diagram) (err error) {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		file := strings.TrimSuffix(name, ".mmd") + ".mmd"
+		if other, exists := files[file]; exists {
+			return fmt.Errorf("WriteZip: entries %q and %q both write %s",
+				other, name, file)
+		}
+		files[file] = name
+	}
+	cli := ""
+	if MermaidCLI != "" {
+		cli, _ = exec.LookPath(MermaidCLI)
+	}
+	z := zip.NewWriter(w)
+	for _, name := range names {
+		if err := writeZipEntry(z, name, entries[name], cli); err != nil {
+			return fmt.Errorf("WriteZip: entry %q: %s", name, err)
+		}
+	}
+	return z.Close()
+}
+
+// Helperfunction to write the files of a single Diagram to the archive.
+func writeZipEntry(z *zip.Writer, name string, d Diagram, cli string) error {
+	if d == nil {
+		return fmt.Errorf("no Diagram given")
+	}
+	base := strings.TrimSuffix(name, ".mmd")
+	code := d.String()
+	f, err := z.Create(base + ".mmd")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, code); err != nil {
+		return err
+	}
+	if cli == "" {
+		return nil
+	}
+	svg, err := renderSVG(cli, code)
+	if err != nil {
+		return err
+	}
+	f, err = z.Create(base + ".svg")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(svg)
+	return err
+}
+
+// Helperfunction to render mermaid code to SVG using the mermaid-cli.
+func renderSVG(cli, code string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "mermaidgen")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "in.mmd")
+	out := filepath.Join(dir, "out.svg")
+	if err := os.WriteFile(in, []byte(code), 0644); err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(cli, "-i", in, "-o", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s: %s", filepath.Base(cli), err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return os.ReadFile(out)
+}
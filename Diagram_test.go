@@ -0,0 +1,75 @@
+package mermaidgen_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/StephenBrown2/mermaidgen"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/gantt"
+)
+
+// all diagram types implement Diagram
+var (
+	_ mermaidgen.Diagram = (*flowchart.Flowchart)(nil)
+	_ mermaidgen.Diagram = (*gantt.Gantt)(nil)
+)
+
+// Packaging many diagrams into a zip archive
+func ExampleWriteZip() {
+	// don't render SVGs, even if mmdc is installed
+	mermaidgen.MermaidCLI = ""
+	fc := flowchart.NewFlowchart()
+	fc.AddEdge(fc.AddNode("a"), fc.AddNode("b"))
+	g, _ := gantt.NewGantt()
+	g.AddTask("t1")
+	var b bytes.Buffer
+	mermaidgen.WriteZip(&b, map[string]mermaidgen.Diagram{
+		"schedule": g, "docs/architecture.mmd": fc,
+	})
+	z, _ := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	for _, f := range z.File {
+		fmt.Println(f.Name)
+	}
+	//Output:
+	//docs/architecture.mmd
+	//schedule.mmd
+}
+
+func TestWriteZip(t *testing.T) {
+	mermaidgen.MermaidCLI = ""
+	g, _ := gantt.NewGantt()
+	g.AddTask("t1")
+	var b bytes.Buffer
+	if err := mermaidgen.WriteZip(&b, map[string]mermaidgen.Diagram{"g": g}); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := z.File[0].Open()
+	code, _ := io.ReadAll(r)
+	if string(code) != g.String() {
+		t.Errorf("unexpected content %q", code)
+	}
+	err = mermaidgen.WriteZip(io.Discard, map[string]mermaidgen.Diagram{
+		"g": g, "missing": nil,
+	})
+	if err == nil || err.Error() != `WriteZip: entry "missing": no Diagram given` {
+		t.Errorf("unexpected error %v", err)
+	}
+	b.Reset()
+	err = mermaidgen.WriteZip(&b, map[string]mermaidgen.Diagram{
+		"x": g, "x.mmd": g,
+	})
+	if err == nil || err.Error() != `WriteZip: entries "x" and "x.mmd" both write x.mmd` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("nothing must be written on colliding names, got %d bytes", b.Len())
+	}
+}
@@ -0,0 +1,17 @@
+/*
+Package mermaidgen is an object oriented approach to define mermaid graphics
+from Go code and render them to mermaid code. The diagram types live in their
+own packages (e.g. flowchart and gantt), this package holds what they have in
+common.
+
+All diagram types implement the Diagram interface, so they can be handled
+alike, e.g. to package many of them into a zip archive using WriteZip.
+
+	fc := flowchart.NewFlowchart()
+	g, _ := gantt.NewGantt()
+	err := mermaidgen.WriteZip(w, map[string]mermaidgen.Diagram{
+		"architecture": fc,
+		"schedule":     g,
+	})
+*/
+package mermaidgen
@@ -18,6 +18,23 @@ const (
 	EShapeLine        edgeShape = `---`
 	EShapeDottedLine  edgeShape = `-.-`
 	EShapeThickLine   edgeShape = `===`
+	// Endings other than arrows and arrows pointing both ways.
+	EShapeCircle        edgeShape = `--o`
+	EShapeCross         edgeShape = `--x`
+	EShapeBidirectional edgeShape = `<-->`
+	EShapeCircleBoth    edgeShape = `o--o`
+	EShapeCrossBoth     edgeShape = `x--x`
+	// Invisible link only influencing the layout, its Text is not rendered.
+	EShapeInvisible edgeShape = `~~~`
+)
+
+type animationSpeed string
+
+// Speeds of animated Edges for Edge's SetAnimationSpeed method. Without a speed
+// mermaid animates Edges with its default speed.
+const (
+	AnimationFast animationSpeed = "fast"
+	AnimationSlow animationSpeed = "slow"
 )
 
 // Edge represents a connection between 2 Nodes.
@@ -25,12 +42,23 @@ const (
 // instances directly. Already defined IDs (indices) can be looked up via
 // Flowchart's GetEdge method or iterated over via its ListEdges method.
 type Edge struct {
-	id    int
-	From  *Node      // Pointer to the Node where the Edge starts.
-	To    *Node      // Pointer to the Node where the Edge ends.
-	Shape edgeShape  // The shape of this Edge.
-	Text  []string   // Optional text lines to be added along the Edge.
-	Style *EdgeStyle // Optional CSS style.
+	id        int
+	From      *Node          // Pointer to the Node where the Edge starts.
+	To        *Node          // Pointer to the Node where the Edge ends.
+	Shape     edgeShape      // The shape of this Edge.
+	Text      []string       // Optional text lines to be added along the Edge.
+	Style     *EdgeStyle     // Optional CSS style.
+	MinLength int            // Optional number of ranks the Edge spans (default 1).
+	Weight    float64        // Optional weight, see LabelEdgesFromWeight.
+	comment   string         // Optional comment rendered above the Edge.
+	name      string         // Optional edge ID, see SetName.
+	animated  bool           // Optional animation, see SetAnimated.
+	class     string         // Optional class, see SetClass.
+	backward  bool           // Optional flipped arrow, see SetDirection.
+	tooltip   string         // Optional hover text, see SetTooltip.
+	speed     animationSpeed // Optional animation speed, see SetAnimationSpeed.
+	// Optional rendering of Text as markdown string, see String.
+	MarkdownText bool
 }
 
 // ID provides access to the Edge's readonly field id.
@@ -40,25 +68,220 @@ func (e *Edge) ID() (id int) {
 	return e.id
 }
 
+// SetComment sets a comment that is rendered as %% line(s) right above this
+// Edge's definition, e.g. to record the source of the Edge. Mermaid ignores
+// comments. Use an empty string to remove the comment.
+func (e *Edge) SetComment(text string) {
+	e.comment = text
+}
+
+// Comment returns the comment set via SetComment.
+func (e *Edge) Comment() (text string) {
+	return e.comment
+}
+
+// SetName sets the edge ID mermaid 11 uses to reference this Edge, e.g. in
+// class lines. If no name is set, e<ID> (e.g. e0) is used. The name is only
+// rendered if it is needed for SetAnimated, SetClass or SetTooltip. An empty
+// string restores the default.
+func (e *Edge) SetName(name string) {
+	e.name = name
+}
+
+// Name returns the edge ID set via SetName or the default e<ID>.
+func (e *Edge) Name() (name string) {
+	if e.name == "" {
+		return "e" + strconv.Itoa(e.id)
+	}
+	return e.name
+}
+
+// SetAnimated sets whether this Edge is rendered animated. Like SetClass this
+// needs a Flowchart targeting MermaidV11, which supports edge IDs, otherwise
+// it is not rendered.
+func (e *Edge) SetAnimated(animated bool) {
+	e.animated = animated
+}
+
+// Animated returns whether this Edge is animated, see SetAnimated.
+func (e *Edge) Animated() (animated bool) {
+	return e.animated
+}
+
+// SetAnimationSpeed sets the speed of this Edge's animation, which mermaid 11
+// renders via "animation: fast" (or slow) instead of "animate: true". The
+// speed is only rendered if the Edge is animated, see SetAnimated. An empty
+// speed restores mermaid's default.
+func (e *Edge) SetAnimationSpeed(speed animationSpeed) {
+	e.speed = speed
+}
+
+// AnimationSpeed returns the speed set via SetAnimationSpeed.
+func (e *Edge) AnimationSpeed() (speed animationSpeed) {
+	return e.speed
+}
+
+// SetClass assigns a class defined via classDef to this Edge, e.g. to style
+// its animation. If the Flowchart targets MermaidV11 the Edge is rendered with
+// its name (see SetName) and a class line referencing it, otherwise the class
+// is not rendered. An empty string removes the class.
+func (e *Edge) SetClass(class string) {
+	e.class = class
+}
+
+// Class returns the class set via SetClass.
+func (e *Edge) Class() (class string) {
+	return e.class
+}
+
+// SetTooltip sets a text shown when hovering this Edge, e.g. explaining why a
+// dependency exists. Like SetAnimated this needs a Flowchart targeting
+// MermaidV11 (the first mermaid version with edge metadata), where the Edge
+// is rendered with its name (see SetName) and a metadata line carrying the
+// tooltip, otherwise it is not rendered. Quotes are escaped, newlines become
+// spaces. An empty string removes the tooltip.
+func (e *Edge) SetTooltip(text string) {
+	e.tooltip = text
+}
+
+// Tooltip returns the text set via SetTooltip.
+func (e *Edge) Tooltip() (text string) {
+	return e.tooltip
+}
+
+// SetDirection sets whether the arrow of this Edge points from From to To
+// (forward, the default) or the other way round, so the data model can stay
+// e.g. From=dependent, To=dependency while the arrow reads "is needed by".
+// Since mermaid has no arrows pointing back, a backward Edge is rendered with
+// its endpoints swapped (To --> From), which mermaid also uses for the layout.
+// Labels and all other settings are rendered as usual. Exports draw the Edge
+// the same way where the format supports it (DOT via dir=back).
+func (e *Edge) SetDirection(forward bool) {
+	e.backward = !forward
+}
+
+// Forward returns whether the arrow of this Edge points from From to To, see
+// SetDirection.
+func (e *Edge) Forward() (forward bool) {
+	return !e.backward
+}
+
+// renderedEnds returns the Nodes in the order they are rendered, see
+// SetDirection.
+func (e *Edge) renderedEnds() (from, to *Node) {
+	if e.backward {
+		return e.To, e.From
+	}
+	return e.From, e.To
+}
+
 // String renders this graph element to an edge definition line.
 // If Style member is set an additional linkStyle line will be created.
+// If MarkdownText is set, the Text is rendered as markdown string like the
+// Text of Nodes, see Node's String.
+// If a comment is set it is rendered above the definition line.
 func (e *Edge) String() (renderedElement string) {
-	line := string(e.Shape)
-	if len(e.Text) > 0 {
-		line += fmt.Sprintf(`|"%s"|`, strings.Join(e.Text, "<br/>"))
+	return e.render(&renderContext{})
+}
+
+// render is the implementation of String.
+func (e *Edge) render(rc *renderContext) string {
+	line := e.renderShape()
+	if len(e.Text) > 0 && e.Shape != EShapeInvisible {
+		unicode := e.From.flowchart != nil && e.From.flowchart.EscapeUnicode
+		if e.MarkdownText {
+			line += fmt.Sprintf(`|"%s"|`, markdownLines(e.Text, unicode))
+		} else {
+			line += fmt.Sprintf(`|"%s"|`, escapeLines(e.Text, unicode))
+		}
 	}
 
-	text := fmt.Sprintf("  %s %s %s\n", e.From.id, line, e.To.id)
+	// edge IDs only exist for styling, which structure only output omits
+	named := (e.animated || e.class != "" || e.tooltip != "") &&
+		!rc.structureOnly && e.From.flowchart != nil &&
+		e.From.flowchart.targets(MermaidV11)
+	if named {
+		line = EscapeID(e.Name()) + "@" + line
+	}
 
-	if e.Style != nil {
-		text += fmt.Sprintf(e.Style.String(), strconv.Itoa(e.id))
+	text := renderComment(e.comment)
+	from, to := e.renderedEnds()
+	text += fmt.Sprintf("  %s %s %s\n", EscapeID(from.id), line,
+		EscapeID(to.id))
+
+	metadata := []string{}
+	if e.animated && e.speed != "" {
+		metadata = append(metadata, "animation: "+string(e.speed))
+	} else if e.animated {
+		metadata = append(metadata, "animate: true")
+	}
+	if e.tooltip != "" {
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+		unicode := e.From.flowchart != nil && e.From.flowchart.EscapeUnicode
+		tooltip := escapeNonASCII(r.Replace(e.tooltip), unicode)
+		metadata = append(metadata, `tooltip: "`+tooltip+`"`)
+	}
+	if named && len(metadata) > 0 {
+		text += fmt.Sprintf("  %s@{ %s }\n", EscapeID(e.Name()),
+			strings.Join(metadata, ", "))
+	}
+	if named && e.class != "" {
+		text += fmt.Sprintf("  class %s %s\n", EscapeID(e.Name()), e.class)
+	}
+
+	if e.Style != nil && !rc.structureOnly {
+		// rendered on its own an Edge keeps its ID as index
+		index, inFlowchart := rc.edgeIndex[e]
+		if !inFlowchart {
+			index = e.id
+		}
+		text += fmt.Sprintf(e.Style.String(), strconv.Itoa(index))
 	}
 
 	return text
 }
 
+// Helperfunction to render the Shape extended to MinLength. Mermaid lengthens
+// links by repeating the dot of dotted shapes or the first dash, equal sign or
+// tilde of all other shapes.
+func (e *Edge) renderShape() string {
+	shape := string(e.Shape)
+	extra := e.MinLength - 1
+	if extra <= 0 || len(shape) < 3 {
+		return shape
+	}
+	if strings.Contains(shape, ".") {
+		return shape[:1] + strings.Repeat(".", extra) + shape[1:]
+	}
+	i := strings.IndexAny(shape, "-=~")
+	if i < 0 {
+		return shape
+	}
+	return shape[:i] + strings.Repeat(shape[i:i+1], extra) + shape[i:]
+}
+
 // AddLines adds one or more lines of text to the Text member.
-// This text gets rendered along the Edge, separated by <br/>'s.
+// This text gets rendered along the Edge, separated by <br/>'s (or line breaks
+// if MarkdownText is set). Lines may contain line breaks themselves ("\n"),
+// which are rendered the same way.
 func (e *Edge) AddLines(lines ...string) {
 	e.Text = append(e.Text, lines...)
 }
+
+// SetLines replaces the Text member by the given lines, which are split at
+// their line breaks like Node's SetLines. Call it without lines to remove the
+// text.
+func (e *Edge) SetLines(lines ...string) {
+	e.Text = nil
+	if len(lines) > 0 {
+		e.Text = splitLines(lines)
+	}
+}
+
+// clone returns a copy of this Edge with its own Text slice. Pointer fields
+// are copied as they are.
+func (e *Edge) clone() *Edge {
+	x := *e
+	x.Text = append([]string(nil), e.Text...)
+	return &x
+}
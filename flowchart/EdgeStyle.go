@@ -35,12 +35,8 @@ func (es *EdgeStyle) ID() (id string) {
 	return es.id
 }
 
-// String renders this graph element to a linkStyle line.
-func (es *EdgeStyle) String() (renderedElement string) {
-	interpolation := ""
-	if es.Interpolation != "" {
-		interpolation = "interpolate " + string(es.Interpolation)
-	}
+// styles returns the CSS definitions of this EdgeStyle.
+func (es *EdgeStyle) styles() []string {
 	styles := []string{}
 	if es.Stroke != "" {
 		styles = append(styles, "stroke:"+string(es.Stroke))
@@ -56,7 +52,16 @@ func (es *EdgeStyle) String() (renderedElement string) {
 	if es.More != "" {
 		styles = append(styles, es.More)
 	}
-	definitions := strings.Join(styles, ",")
+	return styles
+}
+
+// String renders this graph element to a linkStyle line.
+func (es *EdgeStyle) String() (renderedElement string) {
+	interpolation := ""
+	if es.Interpolation != "" {
+		interpolation = "interpolate " + string(es.Interpolation)
+	}
+	definitions := strings.Join(es.styles(), ",")
 	if definitions == "" && interpolation == "" {
 		// neutral element as a fallback to ensure empty linkStyles don't break
 		// the mermaid syntax
@@ -3,7 +3,7 @@ package flowchart_test
 import (
 	"fmt"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with EdgeStyles
@@ -29,11 +29,13 @@ func ExampleEdgeStyle() {
 	fmt.Print(f)
 	//Output:
 	//graph TB
-	//n1["n1"]
-	//n2["n2"]
-	//n1 --> n2
+	//
+	//   n1["n1"]
+	//   n2["n2"]
+	//
+	//   n1 --> n2
 	//linkStyle 0 stroke-width:1px
-	//n1 --> n2
+	//   n1 --> n2
 	//linkStyle 1 stroke:#f00,stroke-width:2px,stroke-dasharray:5px,font-size:20px
 }
 
@@ -2,8 +2,10 @@ package flowchart_test
 
 import (
 	"fmt"
+	"strings"
+	"testing"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with Edges
@@ -29,10 +31,12 @@ func ExampleEdge() {
 	fmt.Print(f)
 	//Output:
 	//graph TB
-	//n1["n1"]
-	//n2["n2"]
-	//n1 -.-|"first line<br/>second line<br/>third line"| n2
-	//n2 --> n1
+	//
+	//   n1["n1"]
+	//   n2["n2"]
+	//
+	//   n1 -.-|"first line<br/>second line<br/>third line"| n2
+	//   n2 --> n1
 	//linkStyle 1 stroke:#0ff
 }
 
@@ -47,3 +51,169 @@ func ExampleEdge_privateFields() {
 	fmt.Println(e2.ID(), e1.ID())
 	//Output: 1 0
 }
+
+// Lengthening Edges to span more ranks
+func ExampleEdge_minLength() {
+	f := flowchart.NewFlowchart()
+	e1 := f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
+	e1.MinLength = 3
+	e1.AddLines("label")
+	fmt.Print(e1)
+	//Output:
+	//   n1 ---->|"label"| n2
+}
+
+// Using circle and cross endings and bidirectional arrows
+func ExampleEdge_endings() {
+	f := flowchart.NewFlowchart()
+	a, b := f.AddNode("a"), f.AddNode("b")
+	f.AddEdgeShaped(a, b, flowchart.EShapeCircle, "")
+	f.AddEdgeShaped(a, b, flowchart.EShapeCross, "")
+	f.AddEdgeShaped(a, b, flowchart.EShapeBidirectional, "sync").MinLength = 2
+	f.AddEdgeShaped(a, b, flowchart.EShapeCircleBoth, "")
+	f.AddEdgeShaped(a, b, flowchart.EShapeCrossBoth, "")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   a["a"]
+	//   b["b"]
+	//
+	//   a --o b
+	//   a --x b
+	//   a <--->|"sync"| b
+	//   a o--o b
+	//   a x--x b
+}
+
+func TestEdge_lengthAndLabel(t *testing.T) {
+	tests := []struct {
+		proto      flowchart.Edge // only the Shape is used
+		connectors [3]string
+	}{
+		{flowchart.Edge{Shape: flowchart.EShapeArrow},
+			[3]string{"-->", "--->", "---->"}},
+		{flowchart.Edge{Shape: flowchart.EShapeDottedArrow},
+			[3]string{"-.->", "-..->", "-...->"}},
+		{flowchart.Edge{Shape: flowchart.EShapeThickArrow},
+			[3]string{"==>", "===>", "====>"}},
+		{flowchart.Edge{Shape: flowchart.EShapeLine},
+			[3]string{"---", "----", "-----"}},
+		{flowchart.Edge{Shape: flowchart.EShapeDottedLine},
+			[3]string{"-.-", "-..-", "-...-"}},
+		{flowchart.Edge{Shape: flowchart.EShapeThickLine},
+			[3]string{"===", "====", "====="}},
+	}
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	for _, test := range tests {
+		for i, connector := range test.connectors {
+			e := f.AddEdge(n1, n2)
+			e.Shape = test.proto.Shape
+			e.MinLength = i + 1
+			want := fmt.Sprintf("  n1 %s n2\n", connector)
+			assert(t, e.String() == want, "got %q, want %q", e.String(), want)
+			e.AddLines("label")
+			want = fmt.Sprintf("  n1 %s|\"label\"| n2\n", connector)
+			assert(t, e.String() == want, "got %q, want %q", e.String(), want)
+		}
+	}
+}
+
+// Flipping the arrow of an Edge without changing the model
+func ExampleEdge_SetDirection() {
+	f := flowchart.NewFlowchart()
+	app, lib := f.AddNode("app"), f.AddNode("lib")
+	e := f.AddEdge(app, lib) // app depends on lib
+	e.AddLines("needed by")
+	e.SetDirection(false)
+	fmt.Print(e)
+	fmt.Println(e.From.ID(), e.Forward())
+	//Output:
+	//  lib -->|"needed by"| app
+	//app false
+}
+
+// Animating Edges with mermaid 11
+func ExampleEdge_SetAnimated() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	e1 := f.AddEdge(n1, n2)
+	e1.SetAnimated(true)
+	e1.SetClass("fast")
+	e2 := f.AddEdge(n2, n1)
+	e2.SetName("back")
+	e2.SetAnimated(true)
+	e2.SetClass("slow")
+	// assigning the class again replaces it
+	e2.SetClass("slow")
+	fmt.Print(e1.String() + e2.String())
+	//Output:
+	//   n1 e0@--> n2
+	//   e0@{ animate: true }
+	//   class e0 fast
+	//   n2 back@--> n1
+	//   back@{ animate: true }
+	//   class back slow
+}
+
+// Setting the speed of animated Edges
+func ExampleEdge_SetAnimationSpeed() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	e := f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
+	e.SetName("flow")
+	e.SetAnimationSpeed(flowchart.AnimationSlow)
+	fmt.Print(e) // not animated yet
+	e.SetAnimated(true)
+	fmt.Print(e)
+	//Output:
+	//n1 --> n2
+	//   n1 flow@--> n2
+	//   flow@{ animation: slow }
+}
+
+// Explaining dependencies on hover with mermaid 11
+func ExampleEdge_SetTooltip() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	e := f.AddEdge(f.AddNode("app"), f.AddNode("lib"))
+	e.SetTooltip(`imports "lib/v2"`)
+	e.SetAnimated(true)
+	fmt.Print(e)
+	f.MermaidVersion = flowchart.MermaidV10
+	fmt.Print(e)
+	//Output:
+	//app e0@--> lib
+	//   e0@{ animate: true, tooltip: "imports \"lib/v2\"" }
+	//   app --> lib
+}
+
+func TestEdge_animatedFallback(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	e := f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
+	e.SetAnimated(true)
+	e.SetClass("fast")
+	assert(t, e.String() == "  n1 --> n2\n", "unexpected %q", e.String())
+	f.MermaidVersion = flowchart.MermaidV11
+	assert(t, !strings.Contains(f.StringStructureOnly(), "e0"),
+		"structure only output must not name Edges")
+}
+
+func TestEdge_SetLines(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	e := f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
+	e.SetLines("reads\r\nwrites", "deletes")
+	assert(t, len(e.Text) == 3, "unexpected lines %q", e.Text)
+	assert(t, e.String() == "  n1 -->|\"reads<br/>writes<br/>deletes\"| n2\n",
+		"unexpected %q", e.String())
+	e.Text = []string{"embedded\nbreak"}
+	e.MarkdownText = true
+	assert(t, e.String() == "  n1 -->|\"`embedded\nbreak`\"| n2\n",
+		"unexpected %q", e.String())
+	e.SetLines()
+	assert(t, e.String() == "  n1 --> n2\n", "unexpected %q", e.String())
+}
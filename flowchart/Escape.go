@@ -0,0 +1,162 @@
+package flowchart
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// replacements used by EscapeLabel, # must come first so the entity codes
+// produced for the other characters aren't escaped again
+var labelEscapes = []struct{ char, entity string }{
+	{"#", "#35;"},
+	{`"`, "#quot;"},
+	{"[", "#91;"},
+	{"]", "#93;"},
+	{"(", "#40;"},
+	{")", "#41;"},
+	{"{", "#123;"},
+	{"}", "#125;"},
+	{"|", "#124;"},
+}
+
+// EscapeLabel escapes a single line of label text, so it can safely be
+// rendered inside any Node shape or Edge label. The characters # " [ ] ( ) { }
+// and | are replaced by mermaid's entity codes (e.g. #quot;), which mermaid
+// renders back to the original characters. This is applied to all Node and
+// Edge texts when rendering, so don't escape them yourself. Set Flowchart's
+// EscapeUnicode to additionally escape all non-ASCII characters.
+func EscapeLabel(s string) (escaped string) {
+	for _, r := range labelEscapes {
+		s = strings.ReplaceAll(s, r.char, r.entity)
+	}
+	return s
+}
+
+// FA returns the reference to a FontAwesome icon mermaid renders in labels,
+// e.g. FA("car") and FA("fa-car") both return "fa:fa-car". Icon references
+// must be separated from other text by spaces, e.g. n.AddLines(FA("car") + "
+// Car"). Mermaid needs the FontAwesome CSS to be loaded by the page, which is
+// not checked. Malformed references in labels are reported by Flowchart's
+// Warnings.
+func FA(icon string) (reference string) {
+	return "fa:fa-" + strings.TrimPrefix(strings.TrimSpace(icon), "fa-")
+}
+
+var (
+	faPrefix    = regexp.MustCompile(`^fa[bsrld]?:`)
+	faReference = regexp.MustCompile(`^fa[bsrld]?:fa-[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// malformedFA returns the first word of the lines starting like a FontAwesome
+// reference (e.g. "fa:") that is not of the form "fa:fa-name".
+func malformedFA(lines []string) string {
+	for _, line := range lines {
+		for _, word := range strings.Fields(line) {
+			if faPrefix.MatchString(word) && !faReference.MatchString(word) {
+				return word
+			}
+		}
+	}
+	return ""
+}
+
+// Keywords of mermaid's flowchart syntax that break the graph if used as IDs.
+var reservedIDs = map[string]bool{
+	"end": true, "graph": true, "flowchart": true, "subgraph": true,
+	"class": true, "classDef": true, "click": true, "style": true,
+	"linkStyle": true, "direction": true, "call": true, "href": true,
+	"default": true,
+}
+
+// EscapeID makes an ID safe to be used as a mermaid Node ID. Letters, digits,
+// underscores and dashes are kept, any other character is replaced by its
+// hexadecimal code point surrounded by underscores, e.g. "a.b" becomes
+// "a_2e_b". An underscore that would read like the start of such a code (e.g.
+// in "a_2e_b" itself) is replaced as "_5f_", so distinct IDs never collide.
+// The first character of mermaid keywords like end or class is replaced the
+// same way, e.g. "end" becomes "_65_nd". This is applied to all Node IDs when
+// rendering, GetNode and ID still use the original ID.
+func EscapeID(s string) (escaped string) {
+	// built backwards, since escaping an underscore depends on what follows
+	escaped = ""
+	runes := []rune(s)
+	for i := len(runes) - 1; i >= 0; i-- {
+		r := runes[i]
+		switch {
+		case i == 0 && reservedIDs[s]:
+			escaped = fmt.Sprintf("_%x_", r) + escaped
+		case r == '_' && escapeCode.MatchString(escaped):
+			escaped = "_5f_" + escaped
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '_', r == '-':
+			escaped = string(r) + escaped
+		default:
+			escaped = fmt.Sprintf("_%x_", r) + escaped
+		}
+	}
+	return escaped
+}
+
+// escapeCode matches the rest of a code produced by EscapeID after its
+// leading underscore.
+var escapeCode = regexp.MustCompile(`^[0-9a-f]+_`)
+
+// splitLines splits lines containing line breaks ("\n" or "\r\n") into
+// separate lines, so they are rendered like lines added one by one.
+func splitLines(lines []string) []string {
+	split := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "\r\n", "\n")
+		split = append(split, strings.Split(line, "\n")...)
+	}
+	return split
+}
+
+// markdownLines escapes each line via escapeText and backticks (which would end
+// the string) as #96;, joins them by line breaks and wraps them as markdown
+// string.
+func markdownLines(lines []string, unicode bool) string {
+	lines = splitLines(lines)
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = strings.ReplaceAll(escapeText(line, unicode), "`", "#96;")
+	}
+	return "`" + strings.Join(escaped, "\n") + "`"
+}
+
+// escapeLines escapes each line via escapeText and joins them by <br/>.
+func escapeLines(lines []string, unicode bool) string {
+	lines = splitLines(lines)
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = escapeText(line, unicode)
+	}
+	return strings.Join(escaped, "<br/>")
+}
+
+// escapeText escapes s via EscapeLabel and, if unicode is set, via
+// escapeNonASCII.
+func escapeText(s string, unicode bool) string {
+	return escapeNonASCII(EscapeLabel(s), unicode)
+}
+
+// escapeNonASCII replaces all non-ASCII characters of s by numeric entity codes
+// (e.g. é becomes #233;) if unicode is set, see Flowchart's EscapeUnicode.
+// Texts that can't take EscapeLabel's entity codes, like tooltips, only get
+// this escaping.
+func escapeNonASCII(s string, unicode bool) string {
+	if !unicode {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r >= utf8.RuneSelf {
+			fmt.Fprintf(&b, "#%d;", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
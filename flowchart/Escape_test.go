@@ -0,0 +1,146 @@
+package flowchart_test
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/StephenBrown2/mermaidgen/flowchart"
+)
+
+// Arbitrary text is escaped when rendering
+func ExampleEscapeLabel() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("my.node")
+	n1.AddLines(`say "hi" [now]`, "#1 (of 2)")
+	e := f.AddEdge(n1, f.AddNode("n2"))
+	e.AddLines("a|b")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   my_2e_node["say #quot;hi#quot; #91;now#93;<br/>#35;1 #40;of 2#41;"]
+	//   n2["n2"]
+	//
+	//   my_2e_node -->|"a#124;b"| n2
+}
+
+// Escaping accents and emoji for renderers that mangle raw Unicode
+func ExampleFlowchart_EscapeUnicode() {
+	f := flowchart.NewFlowchart()
+	f.EscapeUnicode = true
+	n := f.AddNode("café")
+	n.AddLines("Café ✓", "#1 « élan »")
+	f.AddEdge(n, f.AddNode("n2")).AddLines("déjà vu")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   caf_e9_["Caf#233; #10003;<br/>#35;1 #171; #233;lan #187;"]
+	//   n2["n2"]
+	//
+	//   caf_e9_ -->|"d#233;j#224; vu"| n2
+}
+
+func TestFlowchart_EscapeUnicodeEverywhere(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.EscapeUnicode = true
+	f.MermaidVersion = flowchart.MermaidV11
+	n := f.AddNode("n1")
+	n.AddLines("Café")
+	n.Link = "https://example.com"
+	n.Tooltip = "Crème"
+	e := f.AddEdge(n, f.AddNode("n2"))
+	e.SetTooltip("déjà vu")
+	text := f.String()
+	for _, want := range []string{`"Cr#232;me"`, `tooltip: "d#233;j#224; vu"`} {
+		assert(t, strings.Contains(text, want), "want %s in:\n%s", want, text)
+	}
+	mindmap, err := f.Mindmap()
+	assert(t, err == nil, "Mindmap: %v", err)
+	assert(t, strings.Contains(mindmap, `n1["Caf#233;"]`),
+		"want escaped label in:\n%s", mindmap)
+	for _, out := range []string{text, mindmap} {
+		for _, r := range out {
+			assert(t, r < utf8.RuneSelf, "non-ASCII %q in:\n%s", r, out)
+		}
+	}
+}
+
+var entityCode = regexp.MustCompile(`#(quot|[0-9]+);`)
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_-]*$`)
+
+// Adding FontAwesome icons to labels
+func ExampleFA() {
+	f := flowchart.NewFlowchart()
+	car := f.AddNode("car")
+	car.AddLines(flowchart.FA("car") + " Car")
+	bike := f.AddNode("bike")
+	bike.AddLines("fa:bicycle Bike")
+	f.AddEdge(car, bike).AddLines(flowchart.FA("fa-arrow-right"), "fa:fa-")
+	fmt.Print(f)
+	for _, w := range f.Warnings() {
+		fmt.Println(w)
+	}
+	//Output:
+	//graph TB
+	//
+	//   car["fa:fa-car Car"]
+	//   bike["fa:bicycle Bike"]
+	//
+	//   car -->|"fa:fa-arrow-right<br/>fa:fa-"| bike
+	//Node bike: "fa:bicycle" is no FontAwesome reference like "fa:fa-car"
+	//Edge 0: "fa:fa-" is no FontAwesome reference like "fa:fa-car"
+}
+
+func FuzzEscape(f *testing.F) {
+	for _, seed := range []string{"", "plain", `"quoted"`, "[x](y){z}|#1",
+		"#quot;", "ümlaut ✓", "\xff\xfe", "end"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		label := entityCode.ReplaceAllString(flowchart.EscapeLabel(s), "")
+		if strings.ContainsAny(label, "#\"[](){}|") {
+			t.Errorf("EscapeLabel(%q) leaves a delimiter unescaped", s)
+		}
+		id := flowchart.EscapeID(s)
+		if !validID.MatchString(id) {
+			t.Errorf("EscapeID(%q) = %q is no valid ID", s, id)
+		}
+		if utf8.ValidString(s) && unescapeID(id) != s {
+			t.Errorf("EscapeID(%q) = %q decodes to %q", s, id, unescapeID(id))
+		}
+	})
+}
+
+// unescapeID reverts EscapeID, proving that distinct IDs never collide.
+func unescapeID(id string) string {
+	var b strings.Builder
+	for id != "" {
+		if m := escapedRune.FindStringSubmatch(id); m != nil {
+			r, _ := strconv.ParseInt(m[1], 16, 32)
+			b.WriteRune(rune(r))
+			id = id[len(m[0]):]
+			continue
+		}
+		b.WriteByte(id[0])
+		id = id[1:]
+	}
+	return b.String()
+}
+
+var escapedRune = regexp.MustCompile(`^_([0-9a-f]+)_`)
+
+func TestEscapeID(t *testing.T) {
+	for id, want := range map[string]string{
+		"a.b": "a_2e_b", "a_2e_b": "a_5f_2e_b", "billing_db": "billing_db",
+		"end": "_65_nd", "End": "End", "end_": "end_", "a_": "a_",
+	} {
+		if got := flowchart.EscapeID(id); got != want {
+			t.Errorf("EscapeID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,670 @@
+package flowchart
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+////////// Cytoscape.js ////////////////////////////////////////////////////////
+
+// Structs for JSON encode
+type cytoscapeData struct {
+	ID     string `json:"id"`
+	Label  string `json:"label,omitempty"`
+	Parent string `json:"parent,omitempty"`
+	Shape  string `json:"shape,omitempty"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+type cytoscapeElement struct {
+	Data    cytoscapeData `json:"data"`
+	Classes []string      `json:"classes,omitempty"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeJSON struct {
+	Elements cytoscapeElements `json:"elements"`
+}
+
+// mapping of Node shapes to Cytoscape.js node shapes
+var cytoscapeShapes = map[nodeShape]string{
+	NShapeRect:             "rectangle",
+	NShapeRoundRect:        "round-rectangle",
+	NShapeCircle:           "ellipse",
+	NShapeRhombus:          "diamond",
+	NShapeFlagLeft:         "tag",
+	NShapeSubroutine:       "rectangle",
+	NShapeCylinder:         "barrel",
+	NShapeDoubleCircle:     "ellipse",
+	NShapeStadium:          "round-rectangle",
+	NShapeHexagon:          "hexagon",
+	NShapeParallelogram:    "rhomboid",
+	NShapeParallelogramAlt: "right-rhomboid",
+	NShapeTrapezoid:        "rectangle",
+	NShapeTrapezoidAlt:     "rectangle",
+}
+
+// CytoscapeJSON exports the Flowchart to the Cytoscape.js elements JSON format
+// described at https://js.cytoscape.org/#notation/elements-json. Nodes carry
+// their ID, label (Text joined by newlines, the ID if no text is set) and a
+// shape hint usable as "shape: data(shape)", Edges carry source, target and
+// label and get the IDs e0, e1, ... by their index. NodeStyles and EdgeStyles
+// map to classes of the same ID. Subgraphs become compound parent nodes,
+// labeled with their Title. Since Cytoscape.js shares one ID space, an error
+// is returned if a Subgraph ID collides with a Node ID or an Edge ID with a
+// Node or Subgraph ID.
+func (fc *Flowchart) CytoscapeJSON() (data []byte, err error) {
+	export := cytoscapeJSON{Elements: cytoscapeElements{
+		Nodes: []cytoscapeElement{}, Edges: []cytoscapeElement{},
+	}}
+	var walk func(items []graphItem, parent string) error
+	walk = func(items []graphItem, parent string) error {
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				el := cytoscapeElement{Data: cytoscapeData{
+					ID: v.id, Label: nodeLabel(v, "\n"), Parent: parent,
+					Shape: cytoscapeShapes[v.EffectiveShape()],
+				}}
+				if v.Style != nil {
+					el.Classes = []string{v.Style.id}
+				}
+				export.Elements.Nodes = append(export.Elements.Nodes, el)
+			case *Subgraph:
+				if _, collides := fc.nodes[v.id]; collides {
+					return fmt.Errorf(
+						"CytoscapeJSON: Subgraph ID %s collides with a Node ID",
+						v.id)
+				}
+				export.Elements.Nodes = append(export.Elements.Nodes,
+					cytoscapeElement{Data: cytoscapeData{
+						ID: v.id, Label: v.Title, Parent: parent,
+					}})
+				if err := walk(v.items, v.id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(fc.items, ""); err != nil {
+		return nil, err
+	}
+	for _, e := range fc.edges {
+		id, taken := fc.exportEdgeID(e)
+		if taken {
+			return nil, fmt.Errorf(
+				"CytoscapeJSON: Edge ID %s collides with a Node or Subgraph ID", id)
+		}
+		el := cytoscapeElement{Data: cytoscapeData{
+			ID: id, Source: e.From.id, Target: e.To.id,
+			Label: strings.Join(e.Text, "\n"),
+		}}
+		if e.Style != nil {
+			el.Classes = []string{e.Style.id}
+		}
+		export.Elements.Edges = append(export.Elements.Edges, el)
+	}
+	return json.Marshal(export)
+}
+
+// exportEdgeID returns the ID e<index> of an Edge in exports that share one ID
+// space for Nodes, Subgraphs and Edges, taken is set if a Node or Subgraph
+// already uses it.
+func (fc *Flowchart) exportEdgeID(e *Edge) (id string, taken bool) {
+	id = fmt.Sprintf("e%d", e.id)
+	return id, fc.nodes[id] != nil || fc.subgraphs[id] != nil
+}
+
+////////// Graphviz DOT //////////////////////////////////////////////////////
+
+// mapping of Node shapes to Graphviz node attributes
+var dotShapes = map[nodeShape]string{
+	NShapeRect:             "shape=box",
+	NShapeRoundRect:        "shape=box, style=rounded",
+	NShapeCircle:           "shape=circle",
+	NShapeRhombus:          "shape=diamond",
+	NShapeFlagLeft:         "shape=cds",
+	NShapeSubroutine:       "shape=component",
+	NShapeCylinder:         "shape=cylinder",
+	NShapeDoubleCircle:     "shape=doublecircle",
+	NShapeStadium:          "shape=box, style=rounded",
+	NShapeHexagon:          "shape=hexagon",
+	NShapeParallelogram:    "shape=parallelogram",
+	NShapeParallelogramAlt: "shape=parallelogram",
+	NShapeTrapezoid:        "shape=trapezium",
+	NShapeTrapezoidAlt:     "shape=invtrapezium",
+}
+
+// mapping of Edge shapes to Graphviz edge attributes
+var dotEdgeShapes = map[edgeShape]string{
+	EShapeDottedArrow: "style=dotted",
+	EShapeThickArrow:  "penwidth=2",
+	EShapeLine:        "arrowhead=none",
+	EShapeDottedLine:  "style=dotted, arrowhead=none",
+	EShapeThickLine:   "penwidth=2, arrowhead=none",
+	// both heads are set, so dir=back of backward Edges keeps the ending
+	EShapeCircle:        "arrowhead=odot, arrowtail=odot",
+	EShapeCross:         "arrowhead=tee, arrowtail=tee",
+	EShapeBidirectional: "dir=both",
+	EShapeCircleBoth:    "dir=both, arrowhead=odot, arrowtail=odot",
+	EShapeCrossBoth:     "dir=both, arrowhead=tee, arrowtail=tee",
+	EShapeInvisible:     "style=invis",
+}
+
+// Edge shapes looking the same in both directions
+var symmetricEdgeShapes = map[edgeShape]bool{
+	EShapeLine: true, EShapeDottedLine: true, EShapeThickLine: true,
+	EShapeBidirectional: true, EShapeCircleBoth: true, EShapeCrossBoth: true,
+	EShapeInvisible: true,
+}
+
+// DOT exports the Flowchart to the Graphviz DOT language, so it can be laid
+// out by Graphviz the same way mermaid does. The Direction maps to the same
+// rankdir, Subgraphs become clusters (subgraph "cluster_<id>") labeled with
+// their Title. Nodes are labeled like they are rendered by String (Text lines
+// or ID) and get a similar shape, Edges get their Text as label and their
+// Shape as style. All IDs and labels are quoted and escaped as DOT strings.
+// Styles are not exported.
+func (fc *Flowchart) DOT() (dot string) {
+	rankdir := DirectionTopDown
+	switch fc.Direction {
+	case DirectionBottomUp, DirectionLeftRight, DirectionRightLeft:
+		rankdir = fc.Direction
+	}
+	dot = fmt.Sprintf("digraph {\n  rankdir=%s;\n", rankdir)
+	var walk func(items []graphItem, indent string)
+	walk = func(items []graphItem, indent string) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				dot += fmt.Sprintf("%s%s [label=%s, %s];\n", indent,
+					dotQuote(v.id), dotQuote(nodeLabel(v, "\n")),
+					dotShapes[v.EffectiveShape()])
+			case *Subgraph:
+				dot += fmt.Sprintf("%ssubgraph %s {\n%s  label=%s;\n", indent,
+					dotQuote("cluster_"+v.id), indent, dotQuote(v.Title))
+				walk(v.items, indent+"  ")
+				dot += indent + "}\n"
+			}
+		}
+	}
+	walk(fc.items, "  ")
+	for _, e := range fc.edges {
+		attributes := []string{}
+		if len(e.Text) > 0 {
+			attributes = append(attributes,
+				"label="+dotQuote(strings.Join(e.Text, "\n")))
+		}
+		if shape := dotEdgeShapes[e.Shape]; shape != "" {
+			attributes = append(attributes, shape)
+		}
+		if e.backward && !symmetricEdgeShapes[e.Shape] {
+			attributes = append(attributes, "dir=back")
+		}
+		dot += fmt.Sprintf("  %s -> %s", dotQuote(e.From.id), dotQuote(e.To.id))
+		if len(attributes) > 0 {
+			dot += " [" + strings.Join(attributes, ", ") + "]"
+		}
+		dot += ";\n"
+	}
+	return dot + "}\n"
+}
+
+// dotQuote renders s as quoted DOT string, newlines become line breaks.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// nodeLabel joins the Node's Text by sep, falling back to the ID as rendered
+// to mermaid if no text is set.
+func nodeLabel(n *Node, sep string) string {
+	if len(n.Text) > 0 {
+		return strings.Join(n.Text, sep)
+	}
+	return n.id
+}
+
+////////// GraphML ///////////////////////////////////////////////////////////
+
+// mapping of Node shapes to the closest yFiles ShapeNode shape types
+var graphMLShapes = map[nodeShape]string{
+	NShapeRect:             "rectangle",
+	NShapeRoundRect:        "roundrectangle",
+	NShapeCircle:           "ellipse",
+	NShapeRhombus:          "diamond",
+	NShapeFlagLeft:         "fatarrow2",
+	NShapeSubroutine:       "rectangle3d",
+	NShapeCylinder:         "roundrectangle",
+	NShapeDoubleCircle:     "ellipse",
+	NShapeStadium:          "roundrectangle",
+	NShapeHexagon:          "hexagon",
+	NShapeParallelogram:    "parallelogram",
+	NShapeParallelogramAlt: "parallelogram",
+	NShapeTrapezoid:        "trapezoid",
+	NShapeTrapezoidAlt:     "trapezoid2",
+}
+
+// mermaid names of the Node shapes as exported by GraphML
+var graphMLShapeNames = map[nodeShape]string{
+	NShapeRect:             "rect",
+	NShapeRoundRect:        "roundrect",
+	NShapeCircle:           "circle",
+	NShapeRhombus:          "rhombus",
+	NShapeFlagLeft:         "flagleft",
+	NShapeSubroutine:       "subroutine",
+	NShapeCylinder:         "cylinder",
+	NShapeDoubleCircle:     "doublecircle",
+	NShapeStadium:          "stadium",
+	NShapeHexagon:          "hexagon",
+	NShapeParallelogram:    "parallelogram",
+	NShapeParallelogramAlt: "parallelogramalt",
+	NShapeTrapezoid:        "trapezoid",
+	NShapeTrapezoidAlt:     "trapezoidalt",
+}
+
+// header of GraphML documents declaring the attribute keys
+const graphMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key id="direction" for="graph" attr.name="direction" attr.type="string"/>
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="shape" for="node" attr.name="shape" attr.type="string"/>
+  <key id="graphics" for="node" yfiles.type="nodegraphics"/>
+  <key id="edgeLabel" for="edge" attr.name="label" attr.type="string"/>
+`
+
+// GraphML exports the Flowchart to GraphML (http://graphml.graphdrawing.org/),
+// which opens in yEd and other graph editors. The Direction is exported as the
+// graph's direction attribute. Nodes carry their label (like in
+// CytoscapeJSON), their mermaid shape name (e.g. "rhombus") and a yFiles
+// ShapeNode with the closest yEd shape as rendering hint. Subgraphs become
+// nodes labeled with their Title that hold a nested graph of their items.
+// Edges carry source, target and label and get the IDs e0, e1, ... by their
+// index. Styles are not exported. Since GraphML shares one ID space, an error
+// is returned if a Subgraph ID collides with a Node ID or an Edge ID with a
+// Node or Subgraph ID.
+func (fc *Flowchart) GraphML() (data []byte, err error) {
+	var b bytes.Buffer
+	b.WriteString(graphMLHeader)
+	fmt.Fprintf(&b, "  <graph id=\"G\" edgedefault=\"directed\">\n"+
+		"    <data key=\"direction\">%s</data>\n", fc.Direction)
+	var walk func(items []graphItem, indent string) error
+	walk = func(items []graphItem, indent string) error {
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				label := xmlEscape(nodeLabel(v, "\n"))
+				fmt.Fprintf(&b, "%s<node id=\"%s\">\n"+
+					"%s  <data key=\"label\">%s</data>\n"+
+					"%s  <data key=\"shape\">%s</data>\n"+
+					"%s  <data key=\"graphics\"><y:ShapeNode><y:NodeLabel>%s"+
+					"</y:NodeLabel><y:Shape type=\"%s\"/></y:ShapeNode></data>\n"+
+					"%s</node>\n", indent, xmlEscape(v.id), indent, label, indent,
+					graphMLShapeNames[v.EffectiveShape()], indent, label,
+					graphMLShapes[v.EffectiveShape()], indent)
+			case *Subgraph:
+				if _, collides := fc.nodes[v.id]; collides {
+					return fmt.Errorf(
+						"GraphML: Subgraph ID %s collides with a Node ID", v.id)
+				}
+				id := xmlEscape(v.id)
+				fmt.Fprintf(&b, "%s<node id=\"%s\">\n"+
+					"%s  <data key=\"label\">%s</data>\n"+
+					"%s  <graph id=\"%s:\" edgedefault=\"directed\">\n", indent, id,
+					indent, xmlEscape(v.Title), indent, id)
+				if err := walk(v.items, indent+"    "); err != nil {
+					return err
+				}
+				fmt.Fprintf(&b, "%s  </graph>\n%s</node>\n", indent, indent)
+			}
+		}
+		return nil
+	}
+	if err := walk(fc.items, "    "); err != nil {
+		return nil, err
+	}
+	for _, e := range fc.edges {
+		id, taken := fc.exportEdgeID(e)
+		if taken {
+			return nil, fmt.Errorf(
+				"GraphML: Edge ID %s collides with a Node or Subgraph ID", id)
+		}
+		fmt.Fprintf(&b, "    <edge id=\"%s\" source=\"%s\" target=\"%s\"", id,
+			xmlEscape(e.From.id), xmlEscape(e.To.id))
+		if len(e.Text) > 0 {
+			fmt.Fprintf(&b, ">\n      <data key=\"edgeLabel\">%s</data>\n"+
+				"    </edge>\n", xmlEscape(strings.Join(e.Text, "\n")))
+		} else {
+			b.WriteString("/>\n")
+		}
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	return b.Bytes(), nil
+}
+
+// xmlEscape escapes s for use in XML text and attribute values.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+////////// mermaid AST ///////////////////////////////////////////////////////
+
+// ASTVersion is the version of the JSON structure exported by AST. It is
+// increased whenever the structure changes incompatibly.
+const ASTVersion = 1
+
+// Structs for JSON encode, named like the fields of mermaid's flow parser
+type astVertex struct {
+	ID      string   `json:"id"`
+	Text    string   `json:"text"`
+	Type    string   `json:"type"`
+	Styles  []string `json:"styles"`
+	Classes []string `json:"classes"`
+}
+
+type astEdge struct {
+	Start  string   `json:"start"`
+	End    string   `json:"end"`
+	Type   string   `json:"type"`
+	Stroke string   `json:"stroke"`
+	Text   string   `json:"text"`
+	Length int      `json:"length"`
+	Style  []string `json:"style"`
+}
+
+type astSubgraph struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Nodes []string `json:"nodes"`
+}
+
+type astJSON struct {
+	Version   int           `json:"version"`
+	Direction string        `json:"direction"`
+	Vertices  []astVertex   `json:"vertices"`
+	Edges     []astEdge     `json:"edges"`
+	SubGraphs []astSubgraph `json:"subGraphs"`
+}
+
+// mapping of Node shapes to the vertex types of mermaid's flow parser
+var astShapes = map[nodeShape]string{
+	NShapeRect:             "square",
+	NShapeRoundRect:        "round",
+	NShapeCircle:           "circle",
+	NShapeRhombus:          "diamond",
+	NShapeFlagLeft:         "odd",
+	NShapeSubroutine:       "subroutine",
+	NShapeCylinder:         "cylinder",
+	NShapeDoubleCircle:     "doublecircle",
+	NShapeStadium:          "stadium",
+	NShapeHexagon:          "hexagon",
+	NShapeParallelogram:    "lean_right",
+	NShapeParallelogramAlt: "lean_left",
+	NShapeTrapezoid:        "trapezoid",
+	NShapeTrapezoidAlt:     "inv_trapezoid",
+}
+
+// AST exports the Flowchart to JSON shaped like the output of mermaid's flow
+// parser, for tools of the mermaid ecosystem consuming that structure. The
+// object holds the schema "version" (ASTVersion), the "direction" and:
+//
+// "vertices": the Nodes in the order they are rendered with "id", "text"
+// (Text lines joined by <br/>, the ID if no text is set), "type" (the
+// parser's shape name like "square" or "diamond"), "styles" (the minimum
+// size fallback styles) and "classes" (the ID of the NodeStyle).
+//
+// "edges": the Edges in the order they are rendered with "start" and "end"
+// (the Node IDs), "type" ("arrow_point" or "arrow_open"), "stroke" ("normal",
+// "thick" or "dotted"), "text", "length" (the MinLength, at least 1) and
+// "style" (the CSS definitions of the EdgeStyle).
+//
+// "subGraphs": the Subgraphs in the order they are rendered with "id",
+// "title" and "nodes" (the IDs of their direct Nodes and Subgraphs).
+func (fc *Flowchart) AST() (data []byte, err error) {
+	export := astJSON{Version: ASTVersion, Direction: string(fc.Direction),
+		Vertices: []astVertex{}, Edges: []astEdge{}, SubGraphs: []astSubgraph{}}
+	var walk func(items []graphItem)
+	walk = func(items []graphItem) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				vertex := astVertex{ID: v.id, Text: nodeLabel(v, "<br/>"),
+					Type: astShapes[v.EffectiveShape()], Styles: []string{},
+					Classes: []string{}}
+				if w := v.MinWidth(); w > 0 {
+					vertex.Styles = append(vertex.Styles,
+						fmt.Sprintf("min-width:%dpx", w))
+				}
+				if h := v.MinHeight(); h > 0 {
+					vertex.Styles = append(vertex.Styles,
+						fmt.Sprintf("min-height:%dpx", h))
+				}
+				if v.Style != nil {
+					vertex.Classes = append(vertex.Classes, v.Style.id)
+				}
+				export.Vertices = append(export.Vertices, vertex)
+			case *Subgraph:
+				sg := astSubgraph{ID: v.id, Title: v.Title, Nodes: []string{}}
+				for _, child := range v.items {
+					switch c := child.(type) {
+					case *Node:
+						sg.Nodes = append(sg.Nodes, c.id)
+					case *Subgraph:
+						sg.Nodes = append(sg.Nodes, c.id)
+					}
+				}
+				export.SubGraphs = append(export.SubGraphs, sg)
+				walk(v.items)
+			}
+		}
+	}
+	walk(fc.items)
+	edges, _ := fc.renderedEdges()
+	for _, e := range edges {
+		from, to := e.renderedEnds()
+		edge := astEdge{Start: from.id, End: to.id, Type: "arrow_open",
+			Stroke: "normal", Text: strings.Join(e.Text, "<br/>"),
+			Length: e.MinLength, Style: []string{}}
+		switch e.Shape {
+		case EShapeArrow, EShapeDottedArrow, EShapeThickArrow:
+			edge.Type = "arrow_point"
+		case EShapeCircle:
+			edge.Type = "arrow_circle"
+		case EShapeCross:
+			edge.Type = "arrow_cross"
+		case EShapeBidirectional:
+			edge.Type = "double_arrow_point"
+		case EShapeCircleBoth:
+			edge.Type = "double_arrow_circle"
+		case EShapeCrossBoth:
+			edge.Type = "double_arrow_cross"
+		}
+		switch e.Shape {
+		case EShapeDottedArrow, EShapeDottedLine:
+			edge.Stroke = "dotted"
+		case EShapeThickArrow, EShapeThickLine:
+			edge.Stroke = "thick"
+		case EShapeInvisible:
+			edge.Stroke, edge.Text = "invisible", ""
+		}
+		if edge.Length < 1 {
+			edge.Length = 1
+		}
+		if e.Style != nil {
+			edge.Style = e.Style.styles()
+		}
+		export.Edges = append(export.Edges, edge)
+	}
+	return json.Marshal(export)
+}
+
+////////// mindmap /////////////////////////////////////////////////////////////
+
+// Node shapes mindmaps support with the same syntax, others render as NShapeRect
+var mindmapShapes = map[nodeShape]bool{
+	NShapeRect:      true,
+	NShapeRoundRect: true,
+	NShapeCircle:    true,
+	NShapeHexagon:   true,
+}
+
+// Mindmap renders the Flowchart as a mermaid mindmap, which reads better than
+// a graph if the Flowchart is a tree. The Edges point from parent to child
+// Nodes (see SetDirection), children are indented below their parent in the
+// order of the Edges. Nodes keep their text and their rectangle, rounded,
+// circle or hexagon shape, other shapes render as rectangles. Subgraphs and
+// styles are not rendered. An error is returned unless the Flowchart forms a
+// single tree (mermaid mindmaps have exactly one root): if it has no Nodes or
+// several roots, a Node has multiple parents or the Edges form a cycle.
+func (fc *Flowchart) Mindmap() (mindmap string, err error) {
+	nodes := []*Node{}
+	fc.WalkNodes(func(n *Node, _ *Subgraph) { nodes = append(nodes, n) })
+	parent := make(map[*Node]*Node, len(nodes))
+	children := make(map[*Node][]*Node, len(nodes))
+	for _, e := range fc.edges {
+		from, to := e.renderedEnds()
+		if _, exists := parent[to]; exists {
+			return "", fmt.Errorf("Mindmap: Node %s has multiple parents", to.id)
+		}
+		parent[to] = from
+		children[from] = append(children[from], to)
+	}
+	roots := []string{}
+	var root *Node
+	for _, n := range nodes {
+		if _, exists := parent[n]; !exists {
+			roots = append(roots, n.id)
+			root = n
+		}
+	}
+	if len(roots) != 1 {
+		return "", fmt.Errorf("Mindmap: expected a single root Node, found %d: %s",
+			len(roots), strings.Join(roots, ", "))
+	}
+	text, visited := "mindmap\n", 0
+	var walk func(n *Node, depth int)
+	walk = func(n *Node, depth int) {
+		visited++
+		shape := n.EffectiveShape()
+		if !mindmapShapes[shape] {
+			shape = NShapeRect
+		}
+		text += fmt.Sprintf("%s%s"+string(shape)+"\n",
+			strings.Repeat("  ", depth), EscapeID(n.id),
+			escapeText(nodeLabel(n, "<br/>"), fc.EscapeUnicode))
+		for _, child := range children[n] {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 1)
+	if visited < len(nodes) {
+		return "", fmt.Errorf("Mindmap: the Edges form a cycle")
+	}
+	return text, nil
+}
+
+////////// adjacency list //////////////////////////////////////////////////////
+
+// AdjacencyList renders the structure of the Flowchart to a terse, non-mermaid
+// text format, e.g. for quick debugging. There is one line per Node, sorted by
+// ID, that lists the sorted, distinct IDs of the Nodes it has Edges to, like
+// "A -> B, C". Nodes without outgoing Edges are rendered as "A ->".
+func (fc *Flowchart) AdjacencyList() (list string) {
+	return fc.adjacencyList(func(n *Node) string { return n.id })
+}
+
+// AdjacencyListLabeled works like AdjacencyList, but uses the Nodes' labels
+// (Text joined by spaces, the ID if no text is set) instead of their IDs, the
+// lines are sorted by label then.
+func (fc *Flowchart) AdjacencyListLabeled() (list string) {
+	return fc.adjacencyList(func(n *Node) string { return nodeLabel(n, " ") })
+}
+
+// Helperfunction to render the adjacency list naming the Nodes via name.
+func (fc *Flowchart) adjacencyList(name func(*Node) string) string {
+	targets := make(map[*Node]map[string]bool)
+	for _, n := range fc.nodes {
+		targets[n] = make(map[string]bool)
+	}
+	for _, e := range fc.edges {
+		if e.From == nil || e.To == nil {
+			continue
+		}
+		if targets[e.From] == nil {
+			targets[e.From] = make(map[string]bool)
+		}
+		targets[e.From][name(e.To)] = true
+	}
+	lines := make([]string, 0, len(targets))
+	for n, names := range targets {
+		sorted := make([]string, 0, len(names))
+		for x := range names {
+			sorted = append(sorted, x)
+		}
+		sort.Strings(sorted)
+		line := name(n) + " ->"
+		if len(sorted) > 0 {
+			line += " " + strings.Join(sorted, ", ")
+		}
+		lines = append(lines, line+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+////////// GitHub //////////////////////////////////////////////////////////////
+
+// GitHubMarkdown renders the graph to a ```mermaid fenced code block that
+// GitHub renders in Markdown files, issues and comments. GitHub renders the
+// graph in a sandbox without interactions and may lag behind the latest
+// mermaid release, so the graph is rendered with the features listed by
+// UnsupportedOnGitHub stripped or downgraded. The Flowchart itself is not
+// changed.
+func (fc *Flowchart) GitHubMarkdown() (markdown string) {
+	c := fc.Clone()
+	if c.targets(MermaidV11) {
+		c.MermaidVersion = MermaidV10
+	}
+	c.SecurityLevel = ""
+	c.WalkNodes(func(n *Node, _ *Subgraph) {
+		n.Link = ""
+		n.callback, n.args = "", nil
+	})
+	return "```mermaid\n" + c.String() + "```\n"
+}
+
+// UnsupportedOnGitHub returns the features GitHubMarkdown strips or downgrades
+// since GitHub doesn't support them. Nodes are listed in the order of their
+// IDs. If everything is supported, an empty slice is returned.
+func (fc *Flowchart) UnsupportedOnGitHub() (unsupported []string) {
+	unsupported = []string{}
+	if fc.targets(MermaidV11) {
+		unsupported = append(unsupported,
+			"MermaidV11 features are downgraded to MermaidV10")
+	}
+	if fc.SecurityLevel != "" {
+		unsupported = append(unsupported, "the SecurityLevel is removed")
+	}
+	for _, n := range fc.ListNodes() {
+		if n.callback != "" {
+			unsupported = append(unsupported,
+				fmt.Sprintf("the click callback of Node %s is removed", n.id))
+		} else if n.Link != "" {
+			unsupported = append(unsupported,
+				fmt.Sprintf("the click link of Node %s is removed", n.id))
+		}
+	}
+	return unsupported
+}
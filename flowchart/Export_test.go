@@ -0,0 +1,276 @@
+package flowchart_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/StephenBrown2/mermaidgen/flowchart"
+)
+
+// Exporting a Flowchart for Cytoscape.js
+func ExampleFlowchart_CytoscapeJSON() {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("sg1")
+	sg.Title = "backend"
+	n1 := sg.AddNode("api")
+	n1.Shape = flowchart.NShapeRoundRect
+	n1.Style = f.NodeStyle("service")
+	n2 := f.AddNode("db")
+	e := f.AddEdge(n1, n2)
+	e.AddLines("reads")
+	data, err := f.CytoscapeJSON()
+	fmt.Println(string(data), err)
+	// IDs of Subgraphs and Nodes must not collide
+	f.AddSubgraph("db")
+	_, err = f.CytoscapeJSON()
+	fmt.Println(err)
+	//Output:
+	//{"elements":{"nodes":[{"data":{"id":"sg1","label":"backend"}},{"data":{"id":"api","label":"api","parent":"sg1","shape":"round-rectangle"},"classes":["service"]},{"data":{"id":"db","label":"db","shape":"rectangle"}}],"edges":[{"data":{"id":"e0","label":"reads","source":"api","target":"db"}}]}} <nil>
+	//CytoscapeJSON: Subgraph ID db collides with a Node ID
+}
+
+func TestFlowchart_CytoscapeJSONEdgeIDCollision(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.AddEdge(f.AddNode("e0"), f.AddNode("n1"))
+	_, err := f.CytoscapeJSON()
+	want := "CytoscapeJSON: Edge ID e0 collides with a Node or Subgraph ID"
+	assert(t, err != nil && err.Error() == want, "unexpected error %v", err)
+	f = flowchart.NewFlowchart()
+	f.AddSubgraph("e0")
+	f.AddEdge(f.AddNode("n0"), f.AddNode("n1"))
+	_, err = f.CytoscapeJSON()
+	assert(t, err != nil && err.Error() == want, "unexpected error %v", err)
+}
+
+func TestFlowchart_GraphMLEdgeIDCollision(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.AddEdge(f.AddNode("e0"), f.AddNode("n1"))
+	_, err := f.GraphML()
+	want := "GraphML: Edge ID e0 collides with a Node or Subgraph ID"
+	assert(t, err != nil && err.Error() == want, "unexpected error %v", err)
+}
+
+// Rendering a terse adjacency list
+func ExampleFlowchart_AdjacencyList() {
+	f := flowchart.NewFlowchart()
+	a := f.AddNode("a")
+	a.AddLines("Start")
+	b := f.AddNode("b")
+	c := f.AddNode("c")
+	f.AddNode("isolated")
+	f.AddEdge(a, c)
+	f.AddEdge(a, b)
+	f.AddEdge(a, b)
+	f.AddEdge(c, a)
+	fmt.Print(f.AdjacencyList())
+	fmt.Print(f.AdjacencyListLabeled())
+	//Output:
+	//a -> b, c
+	//b ->
+	//c -> a
+	//isolated ->
+	//Start -> b, c
+	//b ->
+	//c -> Start
+	//isolated ->
+}
+
+// Exporting to Graphviz
+func ExampleFlowchart_DOT() {
+	f := flowchart.NewFlowchart()
+	f.Direction = flowchart.DirectionLeftRight
+	frontend := f.AddSubgraph("frontend")
+	frontend.Title = "Frontend"
+	ui := frontend.AddNode("ui")
+	ui.AddLines(`the "UI"`)
+	backend := f.AddSubgraph("backend")
+	backend.Title = "Backend"
+	api := backend.AddNode("api")
+	api.Shape = flowchart.NShapeRoundRect
+	f.AddEdge(ui, api).AddLines("REST", "JSON")
+	f.AddEdge(api, ui).Shape = flowchart.EShapeDottedArrow
+	fmt.Print(f.DOT())
+	//Output:
+	//digraph {
+	//   rankdir=LR;
+	//   subgraph "cluster_frontend" {
+	//     label="Frontend";
+	//     "ui" [label="the \"UI\"", shape=box];
+	//   }
+	//   subgraph "cluster_backend" {
+	//     label="Backend";
+	//     "api" [label="api", shape=box, style=rounded];
+	//   }
+	//   "ui" -> "api" [label="REST\nJSON"];
+	//   "api" -> "ui" [style=dotted];
+	//}
+}
+
+// Publishing a graph on GitHub
+func ExampleFlowchart_GitHubMarkdown() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	f.RankSpacing = 80
+	n1 := f.AddNode("n1")
+	n1.Link = "https://example.com"
+	n1.SetMinWidth(100)
+	n2 := f.AddNode("n2")
+	n2.SetCallback("showDetails")
+	n2.Tooltip = "details"
+	fmt.Print(f.GitHubMarkdown())
+	for _, u := range f.UnsupportedOnGitHub() {
+		fmt.Println(u)
+	}
+	//Output:
+	//```mermaid
+	//%%{init: {"flowchart":{"rankSpacing":80}}}%%
+	//graph TB
+	//
+	//   n1["n1"]
+	//   style n1 min-width:100px
+	//   n2["n2"]
+	//
+	//```
+	//MermaidV11 features are downgraded to MermaidV10
+	//the click link of Node n1 is removed
+	//the click callback of Node n2 is removed
+}
+
+// Exporting a Flowchart for yEd
+func ExampleFlowchart_GraphML() {
+	f := flowchart.NewFlowchart()
+	f.Direction = flowchart.DirectionLeftRight
+	sg := f.AddSubgraph("sg1")
+	sg.Title = "backend"
+	n1 := sg.AddNode("api")
+	n1.Shape = flowchart.NShapeRhombus
+	n2 := f.AddNode("db")
+	n2.AddLines("users & roles")
+	f.AddEdge(n1, n2).AddLines("reads")
+	f.AddEdge(n2, n1)
+	data, err := f.GraphML()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(data))
+	//Output:
+	//<?xml version="1.0" encoding="UTF-8"?>
+	//<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+	//   <key id="direction" for="graph" attr.name="direction" attr.type="string"/>
+	//   <key id="label" for="node" attr.name="label" attr.type="string"/>
+	//   <key id="shape" for="node" attr.name="shape" attr.type="string"/>
+	//   <key id="graphics" for="node" yfiles.type="nodegraphics"/>
+	//   <key id="edgeLabel" for="edge" attr.name="label" attr.type="string"/>
+	//   <graph id="G" edgedefault="directed">
+	//     <data key="direction">LR</data>
+	//     <node id="sg1">
+	//       <data key="label">backend</data>
+	//       <graph id="sg1:" edgedefault="directed">
+	//         <node id="api">
+	//           <data key="label">api</data>
+	//           <data key="shape">rhombus</data>
+	//           <data key="graphics"><y:ShapeNode><y:NodeLabel>api</y:NodeLabel><y:Shape type="diamond"/></y:ShapeNode></data>
+	//         </node>
+	//       </graph>
+	//     </node>
+	//     <node id="db">
+	//       <data key="label">users &amp; roles</data>
+	//       <data key="shape">rect</data>
+	//       <data key="graphics"><y:ShapeNode><y:NodeLabel>users &amp; roles</y:NodeLabel><y:Shape type="rectangle"/></y:ShapeNode></data>
+	//     </node>
+	//     <edge id="e0" source="api" target="db">
+	//       <data key="edgeLabel">reads</data>
+	//     </edge>
+	//     <edge id="e1" source="db" target="api"/>
+	//   </graph>
+	//</graphml>
+}
+
+// Exporting a Flowchart for tools consuming mermaid's parser output
+func ExampleFlowchart_AST() {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("sg1")
+	sg.Title = "backend"
+	n1 := sg.AddNode("api")
+	n1.Shape = flowchart.NShapeRhombus
+	n1.Style = f.NodeStyle("service")
+	e := f.AddEdge(n1, f.AddNode("db"))
+	e.Shape = flowchart.EShapeDottedArrow
+	e.AddLines("reads")
+	e.Style = f.EdgeStyle("es1")
+	e.Style.Stroke = "#f00"
+	data, err := f.AST()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(data))
+	//Output:
+	//{"version":1,"direction":"TB","vertices":[{"id":"api","text":"api","type":"diamond","styles":[],"classes":["service"]},{"id":"db","text":"db","type":"square","styles":[],"classes":[]}],"edges":[{"start":"api","end":"db","type":"arrow_point","stroke":"dotted","text":"reads","length":1,"style":["stroke:#f00"]}],"subGraphs":[{"id":"sg1","title":"backend","nodes":["api"]}]}
+}
+
+// Rendering a tree shaped Flowchart as mindmap
+func ExampleFlowchart_Mindmap() {
+	f := flowchart.NewFlowchart()
+	root := f.AddNode("root")
+	root.Shape = flowchart.NShapeCircle
+	root.AddLines("Project")
+	docs, code := f.AddNode("docs"), f.AddNode("code")
+	f.AddEdge(root, docs)
+	f.AddEdge(root, code)
+	f.AddEdge(code, f.AddNode("tests"))
+	f.AddEdge(f.AddNode("api"), code).SetDirection(false) // drawn code --> api
+	mindmap, err := f.Mindmap()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(mindmap)
+	//Output:
+	//mindmap
+	//   root(("Project"))
+	//     docs["docs"]
+	//     code["code"]
+	//       tests["tests"]
+	//       api["api"]
+}
+
+func TestFlowchart_Mindmap(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	a, b, c := f.AddNode("a"), f.AddNode("b"), f.AddNode("c")
+	f.AddEdge(a, b)
+	if _, err := f.Mindmap(); err == nil ||
+		!strings.Contains(err.Error(), "found 2: a, c") {
+		t.Errorf("expected an error about two roots, got %v", err)
+	}
+	f.AddEdge(c, b)
+	if _, err := f.Mindmap(); err == nil ||
+		!strings.Contains(err.Error(), "Node b has multiple parents") {
+		t.Errorf("expected an error about multiple parents, got %v", err)
+	}
+	g := flowchart.NewFlowchart()
+	x, y, z := g.AddNode("x"), g.AddNode("y"), g.AddNode("z")
+	g.AddEdge(x, y)
+	g.AddEdge(z, z)
+	if _, err := g.Mindmap(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected an error about a cycle, got %v", err)
+	}
+}
+
+func TestFlowchart_DOTEdgeEndings(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	a, b := f.AddNode("a"), f.AddNode("b")
+	f.AddEdgeShaped(a, b, flowchart.EShapeCircle, "").SetDirection(false)
+	f.AddEdgeShaped(a, b, flowchart.EShapeCrossBoth, "").SetDirection(false)
+	dot := f.DOT()
+	for _, want := range []string{
+		`"a" -> "b" [arrowhead=odot, arrowtail=odot, dir=back];`,
+		`"a" -> "b" [dir=both, arrowhead=tee, arrowtail=tee];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected %s in\n%s", want, dot)
+		}
+	}
+}
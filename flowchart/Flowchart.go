@@ -3,11 +3,22 @@ package flowchart
 import (
 	"bytes"
 	"compress/zlib"
+	"container/heap"
+	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"io"
+	"os"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 ////////// ChartDirection //////////////////////////////////////////////////////
@@ -24,11 +35,136 @@ const (
 	DirectionLeftRight chartDirection = `LR`
 )
 
+////////// MermaidVersion ////////////////////////////////////////////////////
+
+type mermaidVersion int
+
+// Mermaid versions a Flowchart can target via its MermaidVersion member.
+// Features only supported by newer mermaid versions are rendered if that
+// version is targeted, otherwise the fallback documented with the feature is
+// used. The default if no MermaidVersion is given is MermaidV10.
+const (
+	MermaidV8  mermaidVersion = 8
+	MermaidV10 mermaidVersion = 10
+	MermaidV11 mermaidVersion = 11
+)
+
+////////// SecurityLevel /////////////////////////////////////////////////////
+
+type securityLevel string
+
+// Security levels of mermaid as described at
+// https://mermaid.js.org/config/usage.html#securitylevel, they decide which
+// click interactions work. The default if no SecurityLevel is given is
+// mermaid's default SecurityStrict. Note that mermaid treats securityLevel as a
+// secure setting by default, so the init directive can't override what the
+// page embedding the graph configured via mermaid.initialize. See Flowchart's
+// Warnings method.
+const (
+	SecurityStrict     securityLevel = "strict"
+	SecurityLoose      securityLevel = "loose"
+	SecurityAntiscript securityLevel = "antiscript"
+	SecuritySandbox    securityLevel = "sandbox"
+)
+
+////////// LineEnding ////////////////////////////////////////////////////////
+
+type lineEnding string
+
+// Line ending definitions used when writing a Flowchart via WriteTo or WriteFile.
+// The default if no LineEnding is given is LineEndingLF.
+const (
+	LineEndingLF   lineEnding = "\n"
+	LineEndingCRLF lineEnding = "\r\n"
+)
+
+// UTF-8 byte order mark, see Flowchart's BOM field.
+const utf8BOM = "\ufeff"
+
 ////////// GraphItem ///////////////////////////////////////////////////////////
 
 // interface to define what can be an "item" to a Flowchart/Subgraph
 type graphItem interface {
-	renderGraph() string
+	renderGraph(rc *renderContext) string
+}
+
+// renderContext carries the settings of a single rendering pass.
+type renderContext struct {
+	sm            *SourceMap    // records the produced lines if not nil
+	structureOnly bool          // omit all styling
+	noMetadata    bool          // omit the metadata comments
+	edgeIndex     map[*Edge]int // linkStyle indices, see renderedEdges
+	topoIndex     map[*Node]int // Node positions if TopologicalOutput applies
+}
+
+// order returns items in the order they are rendered: sorted by the
+// topological position of their Nodes if TopologicalOutput applies, Subgraphs
+// by their first contained Node and empty ones last.
+func (rc *renderContext) order(items []graphItem) []graphItem {
+	if rc.topoIndex == nil {
+		return items
+	}
+	key := func(item graphItem) int {
+		first := len(rc.topoIndex)
+		switch i := item.(type) {
+		case *Node:
+			first = rc.topoIndex[i]
+		case *Subgraph:
+			walkNodes(i.items, i, func(n *Node, _ *Subgraph) {
+				if rc.topoIndex[n] < first {
+					first = rc.topoIndex[n]
+				}
+			})
+		}
+		return first
+	}
+	ordered := append([]graphItem(nil), items...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return key(ordered[i]) < key(ordered[j])
+	})
+	return ordered
+}
+
+// record associates all lines of text with item if a SourceMap is in use and
+// returns text unchanged.
+func (rc *renderContext) record(text string, item interface{}) string {
+	return rc.sm.record(text, item)
+}
+
+////////// SourceMap ///////////////////////////////////////////////////////////
+
+// A SourceMap associates each line of rendered mermaid code with the item that
+// produced it, so parser errors like "error on line 42" can be mapped back to
+// the Node, Edge, Subgraph, NodeStyle or EdgeStyle at fault. Populate it via
+// Flowchart's WriteToMapped method. The zero value is ready to use.
+type SourceMap struct {
+	items []interface{} // producing item per line, nil for structural lines
+}
+
+// Item returns the item that produced the given line. Lines are counted from 1
+// like mermaid does in its error messages. For lines not produced by a
+// specific item (e.g. the graph header or blank lines) and line numbers out of
+// range nil is returned.
+func (sm *SourceMap) Item(line int) (item interface{}) {
+	if line < 1 || len(sm.items) < line {
+		return nil
+	}
+	return sm.items[line-1]
+}
+
+// Lines returns the number of lines recorded to the SourceMap.
+func (sm *SourceMap) Lines() (count int) {
+	return len(sm.items)
+}
+
+// record associates all lines of text with item and returns text unchanged.
+func (sm *SourceMap) record(text string, item interface{}) string {
+	if sm != nil {
+		for i := strings.Count(text, "\n"); i > 0; i-- {
+			sm.items = append(sm.items, item)
+		}
+	}
+	return text
 }
 
 ////////// Flowchart ///////////////////////////////////////////////////////////
@@ -37,14 +173,29 @@ type graphItem interface {
 // constructed around a Flowchart object. Create an instance of Flowchart via
 // Flowchart's constructor NewFlowchart, do not create instances directly.
 type Flowchart struct {
-	nodeStyles       map[string]*NodeStyle // internal storage for NodeStyles
-	edgeStyles       map[string]*EdgeStyle // internal storage for EdgeStyles
-	subgraphs        map[string]*Subgraph  // internal storage for Subgraphs
-	nodes            map[string]*Node      // internal storage for Nodes
-	edges            []*Edge               // internal storage for Edges
-	items            []graphItem           // sub-items to render
-	Direction        chartDirection        // The direction used to render the graph.
-	DefaultEdgeStyle *EdgeStyle            // Define a default linkStyle element.
+	nodeStyles         map[string]*NodeStyle // internal storage for NodeStyles
+	edgeStyles         map[string]*EdgeStyle // internal storage for EdgeStyles
+	subgraphs          map[string]*Subgraph  // internal storage for Subgraphs
+	nodes              map[string]*Node      // internal storage for Nodes
+	edges              []*Edge               // internal storage for Edges
+	items              []graphItem           // sub-items to render
+	Direction          chartDirection        // The direction used to render the graph.
+	DefaultEdgeStyle   *EdgeStyle            // Define a default linkStyle element.
+	LineEnding         lineEnding            // Line ending for WriteTo/WriteFile.
+	BOM                bool                  // Prepend a UTF-8 BOM in WriteTo/WriteFile.
+	MaxNodes           int                   // Optional Node limit for StringSafe.
+	MaxEdges           int                   // Optional Edge limit for StringSafe.
+	MermaidVersion     mermaidVersion        // The targeted mermaid version.
+	NodeSpacing        int                   // Optional spacing between Nodes in px.
+	RankSpacing        int                   // Optional spacing between ranks in px.
+	SecurityLevel      securityLevel         // Optional securityLevel for clicks.
+	CanonicalEdgeOrder bool                  // Render Edges sorted, see SortEdges.
+	EmptyPlaceholder   string                // Optional label shown if there are no Nodes.
+	TopologicalOutput  bool                  // Render Nodes and Edges in topological order.
+	EscapeUnicode      bool                  // Render non-ASCII label and tooltip characters as #NNN;.
+	IconPacks          []string              // Optional icon packs expected, see Warnings.
+	metadata           map[string]string     // Provenance rendered as comments.
+	legend             *Subgraph             // Subgraph of LegendSubgraph.
 }
 
 // NewFlowchart is the constructor used to create a new Flowchart object.
@@ -60,30 +211,204 @@ func NewFlowchart() (newFlowchart *Flowchart) {
 	return f
 }
 
-// String recursively renders the whole graph to mermaid code lines.
+// String recursively renders the whole graph to mermaid code lines. If the
+// graph has no Nodes and EmptyPlaceholder is set (e.g. to "(no data)" for
+// a graph bound to a query without results), a single Node with the ID empty
+// and the placeholder as label is rendered, since mermaid renders graphs
+// without Nodes blank or as an error. If TopologicalOutput is set and the
+// graph has no cycles, the Nodes are rendered in topological order along the
+// Edges (roots first) within each Subgraph, followed by the Edges in the order
+// of their From Nodes. This reads like the flow without changing the graph.
+// Graphs with cycles keep the insertion order, see Warnings.
 func (fc *Flowchart) String() (renderedElement string) {
-	text := fmt.Sprintf("graph %s\n", fc.Direction)
-	if fc.DefaultEdgeStyle != nil {
-		text += fmt.Sprintf(fc.DefaultEdgeStyle.String(), "default")
+	return fc.render(&renderContext{})
+}
+
+// StringStructureOnly renders the graph like String does, but omits all
+// styling (classDef, class, style and linkStyle lines and size hints). The
+// result is still valid mermaid code and only changes if the topology changes,
+// which keeps diffs focused on structural changes.
+func (fc *Flowchart) StringStructureOnly() (renderedElement string) {
+	return fc.render(&renderContext{structureOnly: true})
+}
+
+// SetMetadata sets a key/value pair recording e.g. when and by what the graph
+// was generated. The pairs are rendered as a block of %% comments ("%% key:
+// value") sorted by key at the top of the graph, so mermaid ignores them. Since
+// values like timestamps change on every run, they don't affect Fingerprint
+// and Equal. Line breaks in keys and values are rendered as spaces, so they
+// can't end the comment. An empty value removes the key.
+func (fc *Flowchart) SetMetadata(key, value string) {
+	if value == "" {
+		delete(fc.metadata, key)
+		return
 	}
+	if fc.metadata == nil {
+		fc.metadata = make(map[string]string)
+	}
+	fc.metadata[key] = value
+}
 
-	for _, s := range fc.nodeStyles {
-		text += s.String()
+// Metadata returns the value set for key via SetMetadata.
+func (fc *Flowchart) Metadata(key string) (value string) {
+	return fc.metadata[key]
+}
+
+// MetadataKeys returns the sorted keys set via SetMetadata.
+func (fc *Flowchart) MetadataKeys() (keys []string) {
+	keys = make([]string, 0, len(fc.metadata))
+	for key := range fc.metadata {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	text += "\n"
+// Fingerprint returns a hex encoded SHA-256 hash of the rendered graph, leaving
+// out the metadata (see SetMetadata). Graphs rendering to the same code get the
+// same Fingerprint, e.g. to detect whether a generated diagram changed.
+func (fc *Flowchart) Fingerprint() (hash string) {
+	sum := sha256.Sum256([]byte(fc.render(&renderContext{noMetadata: true})))
+	return hex.EncodeToString(sum[:])
+}
 
-	for _, item := range fc.items {
-		text += item.renderGraph()
+// Equal reports whether both Flowcharts render to the same code, leaving out
+// their metadata (see SetMetadata).
+func (fc *Flowchart) Equal(other *Flowchart) (equal bool) {
+	return other != nil && fc.Fingerprint() == other.Fingerprint()
+}
+
+// StringSafe renders the whole graph like String does, but returns an error
+// instead if the Flowchart exceeds its MaxNodes or MaxEdges limits. This guards
+// downstream renderers against runaway graphs. Limits of 0 mean unlimited.
+// An error is returned for negative NodeSpacing or RankSpacing, too, which
+// String silently omits.
+func (fc *Flowchart) StringSafe() (renderedElement string, err error) {
+	if fc.NodeSpacing < 0 || fc.RankSpacing < 0 {
+		return "", fmt.Errorf(
+			"StringSafe: NodeSpacing %d and RankSpacing %d must not be negative",
+			fc.NodeSpacing, fc.RankSpacing)
+	}
+	nodes, edges := len(fc.nodes), len(fc.edges)
+	if (fc.MaxNodes > 0 && nodes > fc.MaxNodes) ||
+		(fc.MaxEdges > 0 && edges > fc.MaxEdges) {
+		return "", fmt.Errorf(
+			"StringSafe: %d Nodes and %d Edges exceed the limits of %d Nodes"+
+				" and %d Edges", nodes, edges, fc.MaxNodes, fc.MaxEdges)
 	}
+	return fc.String(), nil
+}
 
-	text += "\n"
+// render is the implementation of String.
+func (fc *Flowchart) render(rc *renderContext) string {
+	var text strings.Builder
+	if !rc.noMetadata {
+		unbreak := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+		for _, key := range fc.MetadataKeys() {
+			text.WriteString(rc.record(fmt.Sprintf("%%%% %s: %s\n",
+				unbreak.Replace(key), unbreak.Replace(fc.metadata[key])), nil))
+		}
+	}
+	if config := fc.initConfig(); len(config) > 0 {
+		directive, _ := json.Marshal(config)
+		text.WriteString(rc.record(
+			fmt.Sprintf("%%%%{init: %s}%%%%\n", directive), nil))
+	}
+	text.WriteString(rc.record(fmt.Sprintf("graph %s\n", fc.Direction), nil))
+	if fc.DefaultEdgeStyle != nil && !rc.structureOnly {
+		text.WriteString(rc.record(
+			fmt.Sprintf(fc.DefaultEdgeStyle.String(), "default"),
+			fc.DefaultEdgeStyle))
+	}
 
-	for _, e := range fc.edges {
-		text += e.String()
+	if !rc.structureOnly {
+		for _, s := range fc.listNodeStyles() {
+			text.WriteString(rc.record(s.String(), s))
+		}
 	}
 
-	return text
+	text.WriteString(rc.record("\n", nil))
+
+	if fc.TopologicalOutput {
+		rc.topoIndex = fc.topoOrder(true)
+	}
+	for _, item := range rc.order(fc.items) {
+		text.WriteString(item.renderGraph(rc))
+	}
+	if len(fc.nodes) == 0 && fc.EmptyPlaceholder != "" {
+		// mermaid renders a graph without nodes blank or as error
+		text.WriteString(rc.record(fmt.Sprintf("  empty[\"%s\"]\n",
+			escapeText(fc.EmptyPlaceholder, fc.EscapeUnicode)), nil))
+	}
+
+	text.WriteString(rc.record("\n", nil))
+
+	var edges []*Edge
+	edges, rc.edgeIndex = fc.renderedEdges()
+	for _, e := range edges {
+		text.WriteString(rc.record(e.render(rc), e))
+	}
+
+	return text.String()
+}
+
+// WriteTo renders the whole graph like String does and writes it to w, using
+// the configured LineEnding for every line and a leading UTF-8 byte order mark
+// if BOM is set. It implements io.WriterTo.
+func (fc *Flowchart) WriteTo(w io.Writer) (n int64, err error) {
+	return fc.WriteToMapped(w, nil)
+}
+
+// WriteToMapped works like WriteTo but additionally records which item
+// produced which line of the output to sm, unless sm is nil.
+func (fc *Flowchart) WriteToMapped(w io.Writer, sm *SourceMap) (n int64, err error) {
+	text := fc.render(&renderContext{sm: sm})
+	if fc.LineEnding != "" && fc.LineEnding != LineEndingLF {
+		text = strings.ReplaceAll(text, "\n", string(fc.LineEnding))
+	}
+	if fc.BOM {
+		text = utf8BOM + text
+	}
+	written, err := io.WriteString(w, text)
+	return int64(written), err
+}
+
+// WriteFile creates or truncates the file at the given path and writes the
+// rendered graph to it via WriteTo.
+func (fc *Flowchart) WriteFile(path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = fc.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// TemplateData is the data passed to the template executed by Flowchart's
+// Render method. Since it is an html/template, Diagram is escaped according to
+// its context, e.g. inside a <pre class="mermaid"> element.
+type TemplateData struct {
+	Diagram string      // The rendered graph, see String.
+	LiveURL string      // The URL for the mermaid live editor, see LiveURL.
+	Data    interface{} // The data given to Render.
+}
+
+// Render executes tmpl with a TemplateData holding the rendered graph and the
+// given data and returns the result, e.g. to embed the graph into an HTML page
+// layout. Within the template use {{.Diagram}}, {{.LiveURL}} and {{.Data}}.
+func (fc *Flowchart) Render(tmpl *template.Template, data interface{}) (
+	rendered []byte, err error) {
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, TemplateData{
+		Diagram: fc.String(), LiveURL: fc.LiveURL(), Data: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Render: %s", err)
+	}
+	return b.Bytes(), nil
 }
 
 // Structs for JSON encode
@@ -103,11 +428,24 @@ func (fc *Flowchart) LiveURL() (url string) {
 	data, _ := json.Marshal(dataJSON{
 		Code: fc.String(), Mermaid: mermaidJSON{Theme: "default"},
 	})
+	return liveURL + base64.URLEncoding.EncodeToString(deflate(data))
+}
+
+// zlib writers reused by deflate, allocating one per call dominates LiveURL
+var zlibWriters = sync.Pool{New: func() interface{} {
+	w, _ := zlib.NewWriterLevel(nil, zlib.BestCompression)
+	return w
+}}
+
+// deflate compresses data with zlib like pako does for the live editor.
+func deflate(data []byte) []byte {
 	var b bytes.Buffer
-	w, _ := zlib.NewWriterLevel(&b, zlib.BestCompression)
+	w := zlibWriters.Get().(*zlib.Writer)
+	w.Reset(&b)
 	w.Write(data)
 	w.Close()
-	return liveURL + base64.URLEncoding.EncodeToString(b.Bytes())
+	zlibWriters.Put(w)
+	return b.Bytes()
 }
 
 // ViewInBrowser uses the URL generated by Flowchart's LiveURL method and opens
@@ -182,12 +520,90 @@ func (fc *Flowchart) AddNode(id string) (newNode *Node) {
 	if alreadyExists {
 		return nil
 	}
-	n := &Node{id: id, Shape: NShapeRect}
+	n := &Node{id: id, flowchart: fc, Shape: NShapeRect}
 	fc.nodes[id] = n
 	fc.items = append(fc.items, n)
 	return n
 }
 
+// AddNodeByLabel is used to add a Node with the given label as Text to the
+// Flowchart, whose ID is derived from the label: the first 8 hex digits of
+// its SHA-1 hash. So the same label gets the same ID across runs, which keeps
+// the output stable when generating from data without stable IDs. If a Node
+// with that ID and label already exists, it is returned instead of adding a
+// new one. If the ID is taken by a Node with another label, the suffixes _2,
+// _3, ... are tried until a free or matching ID is found.
+func (fc *Flowchart) AddNodeByLabel(label string) (node *Node) {
+	sum := sha1.Sum([]byte(label))
+	base := hex.EncodeToString(sum[:])[:8]
+	id := base
+	for i := 2; ; i++ {
+		existing, exists := fc.nodes[id]
+		if !exists {
+			break
+		}
+		if len(existing.Text) == 1 && existing.Text[0] == label {
+			return existing
+		}
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	node = fc.AddNode(id)
+	node.AddLines(label)
+	return node
+}
+
+// EscapeReservedIDs renames all Nodes and Subgraphs whose IDs are mermaid
+// keywords like end, class or style, which break the graph. The new ID is the
+// old one with a "_" appended, or "_2", "_3" and so on appended if that is
+// taken by another Node or Subgraph (including those renamed before). Edges
+// keep pointing at the renamed Nodes and Nodes without Text keep displaying
+// their old ID. The returned map holds the new ID for each old one, so callers
+// can update their references, e.g. to look Nodes up via GetNode. A Node and a
+// Subgraph sharing a keyword ID, which mermaid can't tell apart anyway, get the
+// same new ID.
+func (fc *Flowchart) EscapeReservedIDs() (renamed map[string]string) {
+	renamed = make(map[string]string)
+	// renamed items are stored under their new IDs right away, see taken
+	taken := func(id string) bool {
+		_, node := fc.nodes[id]
+		_, subgraph := fc.subgraphs[id]
+		return node || subgraph
+	}
+	safeID := func(id string) string {
+		if newID, exists := renamed[id]; exists {
+			return newID
+		}
+		candidate := id + "_"
+		for i := 2; taken(candidate); i++ {
+			candidate = fmt.Sprintf("%s_%d", id, i)
+		}
+		renamed[id] = candidate
+		return candidate
+	}
+	for _, n := range fc.ListNodes() {
+		if !reservedIDs[n.id] {
+			continue
+		}
+		newID := safeID(n.id)
+		if len(n.Text) == 0 {
+			n.Text = []string{n.id}
+		}
+		delete(fc.nodes, n.id)
+		n.id = newID
+		fc.nodes[newID] = n
+	}
+	for _, sg := range fc.ListSubgraphs() {
+		if !reservedIDs[sg.id] {
+			continue
+		}
+		newID := safeID(sg.id)
+		delete(fc.subgraphs, sg.id)
+		sg.id = newID
+		fc.subgraphs[newID] = sg
+	}
+	return renamed
+}
+
 // AddEdge is used to add a new Edge to the Flowchart. Since Edges have no IDs
 // this will always succeed. The (pseudo) ID is the index that defines the order
 // of all Edges and is used to define linkStyles. The ID can later be used to
@@ -199,6 +615,53 @@ func (fc *Flowchart) AddEdge(from *Node, to *Node) (newEdge *Edge) {
 	return e
 }
 
+// AddEdgeLabeled is used to add a new Edge with the given label to the
+// Flowchart, see AddEdge. An empty label adds no text to the Edge.
+func (fc *Flowchart) AddEdgeLabeled(from, to *Node, label string) (newEdge *Edge) {
+	return fc.AddEdgeShaped(from, to, EShapeArrow, label)
+}
+
+// AddEdgeShaped is used to add a new Edge with the given shape and label to
+// the Flowchart, see AddEdge. An empty label adds no text to the Edge.
+func (fc *Flowchart) AddEdgeShaped(from, to *Node, shape edgeShape,
+	label string) (newEdge *Edge) {
+	e := fc.AddEdge(from, to)
+	e.Shape = shape
+	if label != "" {
+		e.AddLines(label)
+	}
+	return e
+}
+
+// AddInvisibleEdge is used to add a new Edge with the EShapeInvisible shape to
+// the Flowchart, see AddEdge. Invisible Edges are not drawn but laid out like
+// other Edges, e.g. to keep two Nodes next to each other or to pull a Node
+// down by some ranks via MinLength.
+func (fc *Flowchart) AddInvisibleEdge(from, to *Node) (newEdge *Edge) {
+	return fc.AddEdgeShaped(from, to, EShapeInvisible, "")
+}
+
+// LegendSubgraph adds a top level Subgraph with the given title that renders
+// one sample Node per NodeStyle, labeled with the NodeStyle's ID and styled
+// with it. The samples are generated when rendering, so NodeStyles defined
+// later are part of the legend, too. The samples don't show up in ListNodes
+// and similar methods. The Subgraph gets the ID _legend (or _legend2, ... if
+// already taken), the samples get the IDs <legend ID>_<NodeStyle ID> (or
+// <legend ID>_<NodeStyle ID>_2, ... if already taken). Calling LegendSubgraph
+// again just updates the title of the existing legend.
+func (fc *Flowchart) LegendSubgraph(title string) (legend *Subgraph) {
+	if fc.legend == nil {
+		id := "_legend"
+		for i := 2; fc.subgraphs[id] != nil; i++ {
+			id = fmt.Sprintf("_legend%d", i)
+		}
+		fc.legend = fc.AddSubgraph(id)
+		fc.legend.legend = true
+	}
+	fc.legend.Title = title
+	return fc.legend
+}
+
 ////////// get Items ///////////////////////////////////////////////////////////
 
 // GetSubgraph looks up a previously defined Subgraph by its ID.
@@ -229,23 +692,44 @@ func (fc *Flowchart) GetEdge(index int) (existingEdge *Edge) {
 
 ////////// list Items //////////////////////////////////////////////////////////
 
-// ListSubgraphs returns a slice of all previously defined Subgraphs.
-// The order is not well-defined.
+// ListSubgraphs returns a slice of all previously defined Subgraphs in
+// alphabetic order by ID.
 func (fc *Flowchart) ListSubgraphs() (allSubgraphs []*Subgraph) {
 	values := make([]*Subgraph, 0, len(fc.subgraphs))
 	for _, v := range fc.subgraphs {
 		values = append(values, v)
 	}
+	// sort the slice of structs by ID field to provide constant order
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].id < values[j].id
+	})
 	return values
 }
 
-// ListNodes returns a slice of all previously defined Nodes.
-// The order is not well-defined.
+// ListNodes returns a slice of all previously defined Nodes in alphabetic
+// order by ID.
 func (fc *Flowchart) ListNodes() (allNodes []*Node) {
 	values := make([]*Node, 0, len(fc.nodes))
 	for _, v := range fc.nodes {
 		values = append(values, v)
 	}
+	// sort the slice of structs by ID field to provide constant order
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].id < values[j].id
+	})
+	return values
+}
+
+// listNodeStyles returns all NodeStyles in alphabetic order by ID, so they
+// render to a constant order of classDef lines.
+func (fc *Flowchart) listNodeStyles() []*NodeStyle {
+	values := make([]*NodeStyle, 0, len(fc.nodeStyles))
+	for _, v := range fc.nodeStyles {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].id < values[j].id
+	})
 	return values
 }
 
@@ -256,3 +740,1073 @@ func (fc *Flowchart) ListEdges() (allEdges []*Edge) {
 	copy(e, fc.edges)
 	return e
 }
+
+// WalkNodes calls fn for every Node together with the Subgraph directly
+// containing it (nil for top level Nodes), e.g. to write custom exporters.
+// The Nodes are visited in the order they are rendered: the items of the
+// Flowchart in the order they were added, descending depth-first into each
+// Subgraph where it was added.
+func (fc *Flowchart) WalkNodes(fn func(*Node, *Subgraph)) {
+	walkNodes(fc.items, nil, fn)
+}
+
+// Helperfunction to recursively walk the Nodes of items contained in sg.
+func walkNodes(items []graphItem, sg *Subgraph, fn func(*Node, *Subgraph)) {
+	for _, item := range items {
+		switch i := item.(type) {
+		case *Node:
+			fn(i, sg)
+		case *Subgraph:
+			walkNodes(i.items, i, fn)
+		}
+	}
+}
+
+// WalkEdges calls fn for every Edge in the order they were added, e.g. to
+// write custom exporters.
+func (fc *Flowchart) WalkEdges(fn func(*Edge)) {
+	for _, e := range fc.edges {
+		fn(e)
+	}
+}
+
+// initConfig collects the configuration rendered to the init directive, an
+// empty map means no directive is needed. NodeSpacing and RankSpacing are only
+// rendered if they are positive.
+func (fc *Flowchart) initConfig() map[string]interface{} {
+	config := make(map[string]interface{})
+	layout := make(map[string]interface{})
+	if fc.NodeSpacing > 0 {
+		layout["nodeSpacing"] = fc.NodeSpacing
+	}
+	if fc.RankSpacing > 0 {
+		layout["rankSpacing"] = fc.RankSpacing
+	}
+	if len(layout) > 0 {
+		config["flowchart"] = layout
+	}
+	if fc.SecurityLevel != "" {
+		config["securityLevel"] = fc.SecurityLevel
+	}
+	return config
+}
+
+// securityLevel returns the SecurityLevel in use, defaulting to SecurityStrict.
+func (fc *Flowchart) securityLevel() securityLevel {
+	if fc.SecurityLevel == "" {
+		return SecurityStrict
+	}
+	return fc.SecurityLevel
+}
+
+// Warnings returns hints about parts of the graph that render fine but won't
+// work as expected, e.g. click links of Nodes that the SecurityLevel disables.
+// Icons of Nodes must be given as "pack:name". Since icon packs are registered
+// with mermaid by the page embedding the graph, not in the graph, IconPacks
+// can declare the packs the page provides, then icons of other packs are
+// reported too. FontAwesome references in the Text of Nodes and Edges must be
+// given as "fa:fa-name", see FA. The Nodes are checked in the order of their
+// IDs, followed by the Edges. If there is nothing to warn about, an empty
+// slice is returned.
+func (fc *Flowchart) Warnings() (warnings []string) {
+	warnings = []string{}
+	level := fc.securityLevel()
+	packs := make(map[string]bool)
+	for _, pack := range fc.IconPacks {
+		packs[pack] = true
+	}
+	for _, n := range fc.ListNodes() {
+		if n.callback != "" && level != SecurityLoose {
+			warnings = append(warnings, fmt.Sprintf(
+				"Node %s: click callbacks don't work with securityLevel %s",
+				n.id, level))
+		} else if n.callback == "" && n.Link != "" && level == SecuritySandbox {
+			warnings = append(warnings, fmt.Sprintf(
+				"Node %s: click links don't work with securityLevel %s",
+				n.id, level))
+		}
+		if n.Tooltip != "" && n.callback == "" && n.Link == "" {
+			warnings = append(warnings, fmt.Sprintf(
+				"Node %s: the Tooltip needs a Link or callback to be rendered",
+				n.id))
+		}
+		if pack := strings.SplitN(n.Icon, ":", 2); n.Icon != "" &&
+			len(pack) != 2 {
+			warnings = append(warnings, fmt.Sprintf(
+				`Node %s: the Icon "%s" is not given as "pack:name"`, n.id, n.Icon))
+		} else if n.Icon != "" && len(packs) > 0 && !packs[pack[0]] {
+			warnings = append(warnings, fmt.Sprintf(
+				`Node %s: the icon pack "%s" is not in IconPacks`, n.id, pack[0]))
+		}
+		if word := malformedFA(n.Text); word != "" {
+			warnings = append(warnings, fmt.Sprintf(
+				`Node %s: "%s" is no FontAwesome reference like "fa:fa-car"`,
+				n.id, word))
+		}
+	}
+	for _, e := range fc.edges {
+		if word := malformedFA(e.Text); word != "" {
+			warnings = append(warnings, fmt.Sprintf(
+				`Edge %d: "%s" is no FontAwesome reference like "fa:fa-car"`,
+				e.id, word))
+		}
+	}
+	if fc.TopologicalOutput && fc.topoOrder(true) == nil {
+		warnings = append(warnings,
+			"TopologicalOutput: the graph has cycles, keeping the insertion order")
+	}
+	return warnings
+}
+
+// LabelsExceeding returns the Nodes with a rendered label line (a line of
+// Text, the ID if no text is set) longer than maxLen characters, sorted by ID,
+// e.g. to enforce a style guide in CI. Unlike wrapping the labels nothing is
+// modified. If all labels fit, an empty slice is returned.
+func (fc *Flowchart) LabelsExceeding(maxLen int) (exceeding []*Node) {
+	exceeding = []*Node{}
+	for _, n := range fc.ListNodes() {
+		for _, line := range strings.Split(nodeLabel(n, "\n"), "\n") {
+			if utf8.RuneCountInString(line) > maxLen {
+				exceeding = append(exceeding, n)
+				break
+			}
+		}
+	}
+	return exceeding
+}
+
+// targets reports whether the Flowchart targets at least the given version.
+func (fc *Flowchart) targets(version mermaidVersion) bool {
+	if fc.MermaidVersion == 0 {
+		return MermaidV10 >= version
+	}
+	return fc.MermaidVersion >= version
+}
+
+// ReferencedNodeIDs returns the sorted, distinct IDs of all Nodes used as From
+// or To of any Edge. IDs that can't be looked up via GetNode indicate Edges
+// pointing to Nodes of another Flowchart.
+func (fc *Flowchart) ReferencedNodeIDs() (ids []string) {
+	seen := make(map[string]bool)
+	ids = []string{}
+	for _, e := range fc.edges {
+		for _, n := range []*Node{e.From, e.To} {
+			if n != nil && !seen[n.id] {
+				seen[n.id] = true
+				ids = append(ids, n.id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DegreeDistribution returns histograms of the Nodes' degrees, mapping each
+// in-degree (number of incoming Edges) and out-degree (number of outgoing
+// Edges) to the number of Nodes having it, e.g. to spot an unexpected hub
+// caused by bad data. Parallel Edges count separately, a self-loop counts
+// for both degrees of its Node. Edges to Nodes of other Flowcharts are
+// ignored.
+func (fc *Flowchart) DegreeDistribution() (in map[int]int, out map[int]int) {
+	inDegree, outDegree := fc.degrees()
+	in, out = make(map[int]int), make(map[int]int)
+	for _, n := range fc.nodes {
+		in[inDegree[n]]++
+		out[outDegree[n]]++
+	}
+	return in, out
+}
+
+// degrees counts the incoming and outgoing Edges of the Flowchart's Nodes.
+func (fc *Flowchart) degrees() (in, out map[*Node]int) {
+	in, out = make(map[*Node]int), make(map[*Node]int)
+	for _, e := range fc.edges {
+		if e.From == nil || e.To == nil || fc.nodes[e.From.id] != e.From ||
+			fc.nodes[e.To.id] != e.To {
+			continue
+		}
+		out[e.From]++
+		in[e.To]++
+	}
+	return in, out
+}
+
+// ConnectedComponents returns the Nodes of each weakly connected component of
+// the graph, treating Edges as undirected, including the Nodes contained in
+// Subgraphs. Isolated Nodes form components of their own. The Nodes of each
+// component are sorted by ID and the components are ordered by the ID of their
+// first Node. Edges to Nodes that are not part of this Flowchart are ignored.
+func (fc *Flowchart) ConnectedComponents() (components [][]*Node) {
+	neighbours := make(map[*Node][]*Node)
+	for _, e := range fc.edges {
+		if e.From != nil && e.To != nil &&
+			fc.nodes[e.From.id] == e.From && fc.nodes[e.To.id] == e.To {
+			neighbours[e.From] = append(neighbours[e.From], e.To)
+			neighbours[e.To] = append(neighbours[e.To], e.From)
+		}
+	}
+	components = [][]*Node{}
+	seen := make(map[*Node]bool)
+	// ListNodes is sorted, so components are found in the documented order
+	for _, n := range fc.ListNodes() {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		component := []*Node{}
+		for queue := []*Node{n}; len(queue) > 0; queue = queue[1:] {
+			component = append(component, queue[0])
+			for _, x := range neighbours[queue[0]] {
+				if !seen[x] {
+					seen[x] = true
+					queue = append(queue, x)
+				}
+			}
+		}
+		sort.Slice(component, func(i, j int) bool {
+			return component[i].id < component[j].id
+		})
+		components = append(components, component)
+	}
+	return components
+}
+
+// LongestPath returns the Nodes of the longest chain along the Edges from From
+// to To, regardless of SetDirection, e.g. to tell how many sequential steps a
+// pipeline has. Paths are measured by their number of Nodes, or by the sum of
+// their Edges' Weight if any Edge has a Weight. Ties resolve to the path
+// whose Nodes are rendered first. Edges to Nodes that are not part of this
+// Flowchart are ignored. An error is returned if the graph has cycles, where
+// paths can get arbitrarily long. A Flowchart without Nodes has an empty path.
+func (fc *Flowchart) LongestPath() (path []*Node, err error) {
+	topo := fc.topoOrder(false)
+	if topo == nil {
+		return nil, fmt.Errorf("LongestPath: the graph has cycles")
+	}
+	ordered := make([]*Node, len(topo))
+	for n, i := range topo {
+		ordered[i] = n
+	}
+	weighted := false
+	for _, e := range fc.edges {
+		weighted = weighted || e.Weight != 0
+	}
+	outgoing := make(map[*Node][]*Edge)
+	for _, e := range fc.edges {
+		outgoing[e.From] = append(outgoing[e.From], e)
+	}
+	length := make(map[*Node]float64, len(ordered))
+	prev := make(map[*Node]*Node, len(ordered))
+	var end *Node
+	for _, n := range ordered {
+		for _, e := range outgoing[n] {
+			to := e.To
+			if _, inFlowchart := topo[to]; !inFlowchart {
+				continue
+			}
+			step := 1.0
+			if weighted {
+				step = e.Weight
+			}
+			if _, reached := prev[to]; !reached || length[n]+step > length[to] {
+				length[to], prev[to] = length[n]+step, n
+			}
+		}
+		if end == nil || length[n] > length[end] {
+			end = n
+		}
+	}
+	path = []*Node{}
+	for n := end; n != nil; n = prev[n] {
+		path = append([]*Node{n}, path...)
+	}
+	return path, nil
+}
+
+// StronglyConnectedComponents returns the Nodes of each strongly connected
+// component of the graph following the Edges' direction (Tarjan's algorithm),
+// e.g. to locate the feedback loops of a state machine. Components with more
+// than one Node or a Node with a self-loop contain cycles, all other Nodes
+// form components of their own. Like for ConnectedComponents the Nodes of
+// each component are sorted by ID, the components are ordered by the ID of
+// their first Node and Edges to Nodes that are not part of this Flowchart are
+// ignored.
+func (fc *Flowchart) StronglyConnectedComponents() (components [][]*Node) {
+	successors := make(map[*Node][]*Node)
+	for _, e := range fc.edges {
+		if e.From != nil && e.To != nil &&
+			fc.nodes[e.From.id] == e.From && fc.nodes[e.To.id] == e.To {
+			successors[e.From] = append(successors[e.From], e.To)
+		}
+	}
+	components = [][]*Node{}
+	index := make(map[*Node]int)
+	lowlink := make(map[*Node]int)
+	onStack := make(map[*Node]bool)
+	stack := []*Node{}
+	var strongConnect func(n *Node)
+	strongConnect = func(n *Node) {
+		index[n] = len(index)
+		lowlink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, x := range successors[n] {
+			if _, visited := index[x]; !visited {
+				strongConnect(x)
+				if lowlink[x] < lowlink[n] {
+					lowlink[n] = lowlink[x]
+				}
+			} else if onStack[x] && index[x] < lowlink[n] {
+				lowlink[n] = index[x]
+			}
+		}
+		if lowlink[n] != index[n] {
+			return
+		}
+		// n is the root of a component, which is on the stack above it
+		component := []*Node{}
+		for {
+			x := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[x] = false
+			component = append(component, x)
+			if x == n {
+				break
+			}
+		}
+		sort.Slice(component, func(i, j int) bool {
+			return component[i].id < component[j].id
+		})
+		components = append(components, component)
+	}
+	for _, n := range fc.ListNodes() {
+		if _, visited := index[n]; !visited {
+			strongConnect(n)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0].id < components[j][0].id
+	})
+	return components
+}
+
+// DuplicateEdges returns the groups of Edges that are identical in From, To,
+// Shape and Text, so they render to the same line. Unlike MergeParallelEdges
+// nothing is modified. Each group has at least two members in the order they
+// were added, the groups are ordered by their first member. Styles are not
+// compared. If there are no duplicates, an empty slice is returned.
+func (fc *Flowchart) DuplicateEdges() (groups [][]*Edge) {
+	type edgeKey struct {
+		from, to *Node
+		shape    edgeShape
+		text     string
+	}
+	index := make(map[edgeKey]int)
+	all := [][]*Edge{}
+	for _, e := range fc.edges {
+		key := edgeKey{e.From, e.To, e.Shape, strings.Join(e.Text, "\n")}
+		i, found := index[key]
+		if !found {
+			i = len(all)
+			index[key] = i
+			all = append(all, nil)
+		}
+		all[i] = append(all[i], e)
+	}
+	groups = [][]*Edge{}
+	for _, group := range all {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+////////// copy & transform ////////////////////////////////////////////////////
+
+// Clone returns a deep copy of the Flowchart. All Subgraphs, Nodes, Edges and
+// Styles are copied, so modifying the clone doesn't affect the original.
+// Edges pointing to Nodes that are not part of this Flowchart keep pointing to
+// those foreign Nodes.
+func (fc *Flowchart) Clone() (clone *Flowchart) {
+	return fc.copyWhere(nil)
+}
+
+// Snapshot holds the state of a Flowchart at the time it was taken, see
+// Flowchart's Snapshot and Restore. The zero value restores an empty
+// Flowchart.
+type Snapshot struct {
+	state *Flowchart // deep copy of the Flowchart
+}
+
+// Snapshot captures the full state of the Flowchart, e.g. for an undo stack.
+// Since it is a deep copy (see Clone), further changes to the Flowchart don't
+// affect it. Each Snapshot costs about as much memory as the Flowchart itself,
+// so consider limiting the number of Snapshots held for big graphs.
+func (fc *Flowchart) Snapshot() (snapshot Snapshot) {
+	return Snapshot{state: fc.Clone()}
+}
+
+// Restore replaces the state of the Flowchart in place by the given Snapshot,
+// so pointers to the Flowchart stay valid. Pointers to its Nodes, Edges,
+// Subgraphs and Styles don't, since they are replaced by copies of those that
+// were captured. The Snapshot can be restored any number of times.
+func (fc *Flowchart) Restore(snapshot Snapshot) {
+	state := NewFlowchart()
+	if snapshot.state != nil {
+		state = snapshot.state.Clone()
+	}
+	*fc = *state
+	// the copies still belong to state
+	var adopt func(items []graphItem)
+	adopt = func(items []graphItem) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				v.flowchart = fc
+			case *Subgraph:
+				v.flowchart = fc
+				adopt(v.items)
+			}
+		}
+	}
+	adopt(fc.items)
+}
+
+// PruneUnreachable returns a copy of the Flowchart that only contains the
+// Nodes reachable from any of the given roots by following Edges from From to
+// To. The roots themselves are always kept, as long as they belong to this
+// Flowchart. Edges are kept if both of their Nodes are kept and Subgraphs are
+// kept if they (transitively) contain a kept Node. All Styles are preserved.
+func (fc *Flowchart) PruneUnreachable(roots ...*Node) (pruned *Flowchart) {
+	successors := make(map[*Node][]*Node)
+	for _, e := range fc.edges {
+		successors[e.From] = append(successors[e.From], e.To)
+	}
+	keep := make(map[*Node]bool)
+	queue := []*Node{}
+	for _, r := range roots {
+		if r != nil && fc.nodes[r.id] == r && !keep[r] {
+			keep[r] = true
+			queue = append(queue, r)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, s := range successors[n] {
+			if !keep[s] {
+				keep[s] = true
+				queue = append(queue, s)
+			}
+		}
+	}
+	return fc.copyWhere(func(n *Node) bool { return keep[n] })
+}
+
+// TableOfContents returns a new Flowchart with one Node per top level Subgraph
+// of this Flowchart, in the order they were added. Each Node uses the
+// Subgraph's ID, is labeled with its Title (the ID if no Title is set) and
+// links to the URL urlFor returns for that Subgraph, unless it is empty. This
+// is used to build a navigation chart for per-Subgraph detail diagrams.
+func (fc *Flowchart) TableOfContents(urlFor func(*Subgraph) string) (toc *Flowchart) {
+	toc = NewFlowchart()
+	toc.Direction = fc.Direction
+	for _, item := range fc.items {
+		sg, ok := item.(*Subgraph)
+		if !ok {
+			continue
+		}
+		n := toc.AddNode(sg.id)
+		if sg.Title != "" {
+			n.AddLines(sg.Title)
+		}
+		if urlFor != nil {
+			n.Link = urlFor(sg)
+		}
+	}
+	return toc
+}
+
+// SplitBySubgraph splits a large Flowchart into linked per-Subgraph diagrams:
+// The returned map holds the standalone Flowchart of each top level Subgraph
+// (see Subgraph's AsFlowchart) by the Subgraph's ID and an index chart (see
+// TableOfContents) under the key "index", or "index_" and so on if a Subgraph
+// uses that ID. The index Nodes link to "<ID>.mmd", which matches the files
+// mermaidgen.WriteZip writes for the map (its .svg files are only written if
+// mermaid-cli is available; use TableOfContents for other links). Edges
+// between Nodes of different top level Subgraphs are rendered once per pair of
+// Subgraphs in the index, top level Nodes are left out.
+func (fc *Flowchart) SplitBySubgraph() (charts map[string]*Flowchart) {
+	charts = make(map[string]*Flowchart)
+	topLevel := make(map[*Node]*Subgraph)
+	for _, item := range fc.items {
+		if sg, ok := item.(*Subgraph); ok {
+			charts[sg.id] = sg.AsFlowchart()
+			walkNodes(sg.items, sg, func(n *Node, _ *Subgraph) {
+				topLevel[n] = sg
+			})
+		}
+	}
+	index := fc.TableOfContents(func(sg *Subgraph) string {
+		return sg.id + ".mmd"
+	})
+	type pair struct{ from, to *Subgraph }
+	linked := make(map[pair]bool)
+	for _, e := range fc.edges {
+		from, to := topLevel[e.From], topLevel[e.To]
+		if from == nil || to == nil || from == to || linked[pair{from, to}] {
+			continue
+		}
+		linked[pair{from, to}] = true
+		index.AddEdge(index.GetNode(from.id), index.GetNode(to.id))
+	}
+	key := "index"
+	for charts[key] != nil {
+		key += "_"
+	}
+	charts[key] = index
+	return charts
+}
+
+// MergeParallelEdges collapses each group of Edges rendered from the same Node
+// to the same one (see SetDirection) into the first Edge of that group. Its
+// label becomes the non-empty labels of the group joined by sep, where the
+// lines of each label are kept and sep joins the last line of one label with
+// the first line of the next. If the first Edge uses the default EShapeArrow,
+// the first non-default Shape of the group is used. Its Style, name,
+// animation, class, tooltip and comment are the first ones set within the
+// group and its MinLength the largest one. Edges between the same Nodes in
+// opposite directions are left alone. The remaining Edges keep their order but
+// get new IDs (indices). The number of removed Edges is returned.
+func (fc *Flowchart) MergeParallelEdges(sep string) (merged int) {
+	type endpoints struct{ from, to *Node }
+	groups := make(map[endpoints][]*Edge)
+	for _, e := range fc.edges {
+		from, to := e.renderedEnds()
+		key := endpoints{from, to}
+		groups[key] = append(groups[key], e)
+	}
+	remaining := []*Edge{}
+	for _, e := range fc.edges {
+		from, to := e.renderedEnds()
+		group := groups[endpoints{from, to}]
+		if group[0] != e {
+			merged++
+			continue
+		}
+		if len(group) > 1 {
+			lines := []string{}
+			for _, x := range group {
+				if strings.Join(x.Text, "") != "" {
+					if len(lines) > 0 {
+						lines[len(lines)-1] += sep + x.Text[0]
+						lines = append(lines, x.Text[1:]...)
+					} else {
+						lines = append(lines, x.Text...)
+					}
+				}
+				e.mergeSettings(x)
+			}
+			e.Text = nil
+			if len(lines) > 0 {
+				e.Text = lines
+			}
+		}
+		e.id = len(remaining)
+		remaining = append(remaining, e)
+	}
+	fc.edges = remaining
+	return merged
+}
+
+// Helperfunction for MergeParallelEdges to take over the settings of x that
+// this Edge doesn't have yet.
+func (e *Edge) mergeSettings(x *Edge) {
+	if e.Shape == EShapeArrow && x.Shape != EShapeArrow {
+		e.Shape = x.Shape
+	}
+	if e.Style == nil {
+		e.Style = x.Style
+	}
+	if x.MinLength > e.MinLength {
+		e.MinLength = x.MinLength
+	}
+	if e.name == "" {
+		e.name = x.name
+	}
+	if !e.animated && x.animated {
+		e.animated, e.speed = true, x.speed
+	}
+	if e.class == "" {
+		e.class = x.class
+	}
+	if e.tooltip == "" {
+		e.tooltip = x.tooltip
+	}
+	if e.comment == "" {
+		e.comment = x.comment
+	}
+}
+
+// DedupeNodesByLabel merges Nodes that render the same label (Text or ID) in
+// the same shape into one, e.g. when the same real-world entity appears in
+// several source diagrams. See DedupeNodesWhere for details.
+func (fc *Flowchart) DedupeNodesByLabel() (merged int) {
+	return fc.DedupeNodesWhere(func(n *Node) string {
+		return string(n.EffectiveShape()) + "\x00" + nodeLabel(n, "\n")
+	})
+}
+
+// DedupeNodesWhere merges all Nodes that key returns the same non-empty string
+// for into one, Nodes with an empty key are left alone. Of each group the Node
+// with the lowest ID is kept, all Edges of the other Nodes are rewired to it
+// and the other Nodes are removed from the Flowchart and their Subgraphs. The
+// number of removed Nodes is returned. Rewired Edges may become parallel, see
+// MergeParallelEdges.
+func (fc *Flowchart) DedupeNodesWhere(key func(*Node) string) (merged int) {
+	kept := make(map[string]*Node)
+	replace := make(map[*Node]*Node)
+	for _, n := range fc.ListNodes() {
+		k := key(n)
+		if k == "" {
+			continue
+		}
+		if x, exists := kept[k]; exists {
+			replace[n] = x
+			continue
+		}
+		kept[k] = n
+	}
+	if len(replace) == 0 {
+		return 0
+	}
+	for _, e := range fc.edges {
+		if x, ok := replace[e.From]; ok {
+			e.From = x
+		}
+		if x, ok := replace[e.To]; ok {
+			e.To = x
+		}
+	}
+	for n := range replace {
+		delete(fc.nodes, n.id)
+	}
+	fc.items = withoutNodes(fc.items, replace)
+	return len(replace)
+}
+
+// withoutNodes returns items without the Nodes that are keys of drop, removing
+// them from Subgraphs, too.
+func withoutNodes(items []graphItem, drop map[*Node]*Node) []graphItem {
+	remaining := []graphItem{}
+	for _, item := range items {
+		switch v := item.(type) {
+		case *Node:
+			if _, dropped := drop[v]; dropped {
+				continue
+			}
+		case *Subgraph:
+			v.items = withoutNodes(v.items, drop)
+		}
+		remaining = append(remaining, item)
+	}
+	return remaining
+}
+
+// SortEdges reorders the Edges by the IDs of their From and To Nodes and their
+// Text, so the rendered graph doesn't depend on the order the Edges were added
+// in, e.g. when generating from an unordered data source. Edges that compare
+// equal keep their order. The Edges' IDs are reassigned to their new indices,
+// which changes GetEdge lookups and default edge names (see Edge's SetName).
+// Set CanonicalEdgeOrder instead to only render the Edges in this order.
+func (fc *Flowchart) SortEdges() {
+	fc.edges = fc.canonicalEdges()
+	for i, e := range fc.edges {
+		e.id = i
+	}
+}
+
+// LabelEdgesFromWeight replaces the Text of each Edge with a non-zero Weight by
+// the label format returns for it, e.g. to render latencies or costs kept as
+// numbers. Edges without Weight keep their Text, use LabelAllEdgesFromWeight
+// to label them as well.
+func (fc *Flowchart) LabelEdgesFromWeight(format func(weight float64) string) {
+	for _, e := range fc.edges {
+		if e.Weight != 0 {
+			e.Text = []string{format(e.Weight)}
+		}
+	}
+}
+
+// LabelAllEdgesFromWeight works like LabelEdgesFromWeight, but labels the
+// Edges without Weight, too.
+func (fc *Flowchart) LabelAllEdgesFromWeight(format func(weight float64) string) {
+	for _, e := range fc.edges {
+		e.Text = []string{format(e.Weight)}
+	}
+}
+
+// renderedEdges returns the Edges in the order they are rendered and the index
+// mermaid assigns to each of them, which is its position in that order. All
+// Edges of a Flowchart are rendered via this, so linkStyle lines address the
+// right edges whatever order the Edges are rendered in.
+func (fc *Flowchart) renderedEdges() (edges []*Edge, index map[*Edge]int) {
+	edges = fc.edges
+	if fc.CanonicalEdgeOrder {
+		edges = fc.canonicalEdges()
+	}
+	var topo map[*Node]int
+	if fc.TopologicalOutput {
+		topo = fc.topoOrder(true)
+	}
+	if topo != nil {
+		edges = append([]*Edge(nil), edges...)
+		sort.SliceStable(edges, func(i, j int) bool {
+			fromI, toI := edges[i].renderedEnds()
+			fromJ, toJ := edges[j].renderedEnds()
+			if topo[fromI] != topo[fromJ] {
+				return topo[fromI] < topo[fromJ]
+			}
+			return topo[toI] < topo[toJ]
+		})
+	}
+	index = make(map[*Edge]int, len(edges))
+	for i, e := range edges {
+		index[e] = i
+	}
+	return edges, index
+}
+
+// topoOrder returns the position of each Node in a topological order of the
+// Edges from From to To, or of the rendered Edges (see SetDirection) if
+// rendered is set, roots first and ties broken by the order the Nodes are
+// rendered in. Edges to Nodes that are not part of this Flowchart are ignored.
+// If the graph has cycles, nil is returned.
+func (fc *Flowchart) topoOrder(rendered bool) map[*Node]int {
+	nodes := []*Node{}
+	position := make(map[*Node]int)
+	fc.WalkNodes(func(n *Node, _ *Subgraph) {
+		position[n] = len(nodes)
+		nodes = append(nodes, n)
+	})
+	incoming := make([]int, len(nodes))
+	successors := make([][]int, len(nodes))
+	for _, e := range fc.edges {
+		from, to := e.From, e.To
+		if rendered {
+			from, to = e.renderedEnds()
+		}
+		i, fromOK := position[from]
+		j, toOK := position[to]
+		if !fromOK || !toOK {
+			continue
+		}
+		successors[i] = append(successors[i], j)
+		incoming[j]++
+	}
+	// Kahn's algorithm, always picking the first ready Node in render order
+	ready := &positionHeap{}
+	for i := range nodes {
+		if incoming[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	order := make(map[*Node]int, len(nodes))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		order[nodes[i]] = len(order)
+		for _, j := range successors[i] {
+			if incoming[j]--; incoming[j] == 0 {
+				heap.Push(ready, j)
+			}
+		}
+	}
+	if len(order) < len(nodes) {
+		return nil
+	}
+	return order
+}
+
+// positionHeap is a min-heap of render positions, see topoOrder.
+type positionHeap []int
+
+func (h positionHeap) Len() int            { return len(h) }
+func (h positionHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h positionHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *positionHeap) Push(x interface{}) { *h = append(*h, x.(int)) }
+func (h *positionHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// canonicalEdges returns the Edges sorted like SortEdges does.
+func (fc *Flowchart) canonicalEdges() []*Edge {
+	edges := append([]*Edge(nil), fc.edges...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From.id != b.From.id {
+			return a.From.id < b.From.id
+		}
+		if a.To.id != b.To.id {
+			return a.To.id < b.To.id
+		}
+		return strings.Join(a.Text, "\n") < strings.Join(b.Text, "\n")
+	})
+	return edges
+}
+
+// GroupIntoSubgraph moves the given top level Nodes into a new top level
+// Subgraph with the given ID and Title, e.g. to tidy up a linear chain of a
+// sprawling flat graph. The Subgraph takes the place of the first of these
+// Nodes that was added to the Flowchart and contains them in the given order.
+// Since Edges are defined on the Flowchart, all Edges stay as they are, so
+// Edges crossing the Subgraph's boundary keep connecting to its members. An
+// error is returned and nothing is changed if the ID already exists, no Nodes
+// are given or a Node is not a top level Node of this Flowchart.
+func (fc *Flowchart) GroupIntoSubgraph(id, title string, nodes ...*Node) (
+	newSubgraph *Subgraph, err error) {
+	if _, alreadyExists := fc.subgraphs[id]; alreadyExists {
+		return nil, fmt.Errorf("GroupIntoSubgraph: id %s already exists", id)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("GroupIntoSubgraph: no Nodes given")
+	}
+	topLevel := make(map[*Node]bool)
+	for _, item := range fc.items {
+		if n, ok := item.(*Node); ok {
+			topLevel[n] = true
+		}
+	}
+	sg := &Subgraph{id: id, flowchart: fc, Title: title}
+	moving := make(map[*Node]bool)
+	for _, n := range nodes {
+		if n == nil || fc.nodes[n.id] != n {
+			return nil, fmt.Errorf(
+				"GroupIntoSubgraph: Node is not part of this Flowchart")
+		}
+		if !topLevel[n] {
+			return nil, fmt.Errorf(
+				"GroupIntoSubgraph: Node %s is already in Subgraph %s", n.id,
+				fc.containingSubgraph(n).id)
+		}
+		if !moving[n] {
+			moving[n] = true
+			sg.items = append(sg.items, n)
+		}
+	}
+	items := []graphItem{}
+	placed := false
+	for _, item := range fc.items {
+		if n, ok := item.(*Node); ok && moving[n] {
+			if !placed {
+				items = append(items, sg)
+				placed = true
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	fc.items = items
+	fc.subgraphs[id] = sg
+	return sg, nil
+}
+
+// GroupByMeta moves the Nodes into one Subgraph per distinct value of their
+// Meta[key], so groups carried by the data don't need to be built by hand.
+// The Subgraphs use the values as IDs and the titles titleFor returns (the
+// values if titleFor is nil); they are created like GroupIntoSubgraph does,
+// in the order of the sorted values. If a Subgraph with that ID already
+// exists, the Nodes are appended to it instead. Nodes without the key stay
+// where they are, so do Nodes already in their group's Subgraph. An error is
+// returned and nothing is changed if a Node with the key is in another
+// Subgraph.
+func (fc *Flowchart) GroupByMeta(key string, titleFor func(value string) string) (err error) {
+	groups := make(map[string][]*Node)
+	values := []string{}
+	var conflict error
+	fc.WalkNodes(func(n *Node, sg *Subgraph) {
+		value, ok := n.Meta[key]
+		if !ok || conflict != nil {
+			return
+		}
+		if sg != nil {
+			if sg.id != value {
+				conflict = fmt.Errorf(
+					"GroupByMeta: Node %s with %s %s is already in Subgraph %s",
+					n.id, key, value, sg.id)
+			}
+			return
+		}
+		if _, seen := groups[value]; !seen {
+			values = append(values, value)
+		}
+		groups[value] = append(groups[value], n)
+	})
+	if conflict != nil {
+		return conflict
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		if sg, exists := fc.subgraphs[value]; exists {
+			moving := make(map[*Node]*Node)
+			for _, n := range groups[value] {
+				moving[n] = n
+			}
+			// the moving Nodes are top level Nodes, remove them before adding
+			fc.items = withoutNodes(fc.items, moving)
+			for _, n := range groups[value] {
+				sg.items = append(sg.items, n)
+			}
+			continue
+		}
+		title := value
+		if titleFor != nil {
+			title = titleFor(value)
+		}
+		if _, err := fc.GroupIntoSubgraph(value, title, groups[value]...); err != nil {
+			return fmt.Errorf("GroupByMeta: %s", err)
+		}
+	}
+	return nil
+}
+
+// containingSubgraph returns the Subgraph that directly contains the given
+// Node, nil if it is a top level Node or not part of this Flowchart.
+func (fc *Flowchart) containingSubgraph(n *Node) *Subgraph {
+	for _, sg := range fc.subgraphs {
+		if containsItem(sg.items, n) {
+			return sg
+		}
+	}
+	return nil
+}
+
+// Helperfunction to check whether items contain the given item.
+func containsItem(items []graphItem, item graphItem) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// copyWhere deep copies the Flowchart. If keep is nil everything is copied,
+// otherwise only the Nodes keep returns true for, the Edges between them and
+// the non-empty Subgraphs containing them are copied.
+func (fc *Flowchart) copyWhere(keep func(*Node) bool) *Flowchart {
+	c := NewFlowchart()
+	c.Direction = fc.Direction
+	c.LineEnding = fc.LineEnding
+	c.BOM = fc.BOM
+	c.MaxNodes = fc.MaxNodes
+	c.MaxEdges = fc.MaxEdges
+	c.MermaidVersion = fc.MermaidVersion
+	c.NodeSpacing = fc.NodeSpacing
+	c.RankSpacing = fc.RankSpacing
+	c.SecurityLevel = fc.SecurityLevel
+	c.CanonicalEdgeOrder = fc.CanonicalEdgeOrder
+	c.TopologicalOutput = fc.TopologicalOutput
+	c.EscapeUnicode = fc.EscapeUnicode
+	c.IconPacks = append([]string(nil), fc.IconPacks...)
+	c.EmptyPlaceholder = fc.EmptyPlaceholder
+	for key, value := range fc.metadata {
+		c.SetMetadata(key, value)
+	}
+	nodeStyles := make(map[*NodeStyle]*NodeStyle)
+	copyNodeStyle := func(s *NodeStyle) *NodeStyle {
+		if s == nil {
+			return nil
+		}
+		if x, found := nodeStyles[s]; found {
+			return x
+		}
+		x := *s
+		nodeStyles[s] = &x
+		return &x
+	}
+	edgeStyles := make(map[*EdgeStyle]*EdgeStyle)
+	copyEdgeStyle := func(s *EdgeStyle) *EdgeStyle {
+		if s == nil {
+			return nil
+		}
+		if x, found := edgeStyles[s]; found {
+			return x
+		}
+		x := *s
+		edgeStyles[s] = &x
+		return &x
+	}
+	for id, s := range fc.nodeStyles {
+		c.nodeStyles[id] = copyNodeStyle(s)
+	}
+	for id, s := range fc.edgeStyles {
+		c.edgeStyles[id] = copyEdgeStyle(s)
+	}
+	c.DefaultEdgeStyle = copyEdgeStyle(fc.DefaultEdgeStyle)
+
+	nodes := make(map[*Node]*Node)
+	var copyItems func(items []graphItem) []graphItem
+	copyItems = func(items []graphItem) []graphItem {
+		result := []graphItem{}
+		for _, item := range items {
+			switch v := item.(type) {
+			case *Node:
+				if keep != nil && !keep(v) {
+					continue
+				}
+				n := v.clone()
+				n.flowchart = c
+				n.Style = copyNodeStyle(v.Style)
+				nodes[v] = n
+				c.nodes[n.id] = n
+				result = append(result, n)
+			case *Subgraph:
+				subItems := copyItems(v.items)
+				if keep != nil && len(subItems) == 0 {
+					continue
+				}
+				s := v.clone(c)
+				if v == fc.legend {
+					c.legend = s
+				}
+				s.items = subItems
+				for _, subItem := range subItems {
+					if child, ok := subItem.(*Subgraph); ok {
+						child.parent = s
+					}
+				}
+				c.subgraphs[s.id] = s
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	c.items = copyItems(fc.items)
+
+	for _, e := range fc.edges {
+		from, fromFound := nodes[e.From]
+		to, toFound := nodes[e.To]
+		if !fromFound || !toFound {
+			if keep != nil {
+				continue
+			}
+			// foreign Nodes are kept as they are
+			if !fromFound {
+				from = e.From
+			}
+			if !toFound {
+				to = e.To
+			}
+		}
+		x := e.clone()
+		x.From, x.To = from, to
+		x.Style = copyEdgeStyle(e.Style)
+		c.edges = append(c.edges, x)
+		x.id = len(c.edges) - 1
+	}
+	return c
+}
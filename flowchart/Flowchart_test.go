@@ -1,9 +1,15 @@
 package flowchart_test
 
 import (
+	"bytes"
 	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with Flowcharts
@@ -45,7 +51,7 @@ func ExampleFlowchart() {
 	}
 	//Output:
 	//<nil> <nil> <nil>
-	//<nil> <nil> n1 --> n2
+	//<nil> <nil>   n1 --> n2
 	//
 	//sg1
 	//n1
@@ -60,5 +66,1101 @@ func ExampleFlowchart_liveEditorLinks() {
 	f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
 	// you can also use f.ViewInBrowser() to open the URL in browser directly
 	fmt.Println(f.LiveURL())
-	// Output: https://mermaid.live/view/#pako:eNqqVkrOT0lVslJKL0osyFAIcYrJyzOMjlHKM4xRio3JyzMCsY0gbEMFXd2YUgMD41SFPKOYPCUdpdzUotzEzBQlq2qlkozUXJA5KalpiaU5JUq1tYAAAAD__yEwHQk=
+	// Output: https://mermaid.live/view/#pako:eNqqVkrOT0lVslJKL0osyFAIcYrJi8lTUMgzjI5RyjOMUYoF84xAPCMwDyKroKsbU2pgYJyqkGcUk6eko5SbWpSbmJmiZFWtVJKRmgsyMSU1LbE0p0SpthYwAB75H10=
+}
+
+// Writing a Flowchart for Windows based tools
+func ExampleFlowchart_WriteTo() {
+	f := flowchart.NewFlowchart()
+	f.AddEdge(f.AddNode("n1"), f.AddNode("n2"))
+	f.LineEnding = flowchart.LineEndingCRLF
+	f.BOM = true
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	fmt.Printf("%q\n", b.String())
+	//Output:
+	//"\ufeffgraph TB\r\n\r\n  n1[\"n1\"]\r\n  n2[\"n2\"]\r\n\r\n  n1 --> n2\r\n"
+}
+
+// Carving the reachable part out of a Flowchart
+func ExampleFlowchart_PruneUnreachable() {
+	f := flowchart.NewFlowchart()
+	sg1 := f.AddSubgraph("sg1")
+	sg1.Title = "runbook"
+	sg2 := f.AddSubgraph("sg2")
+	sg2.Title = "unrelated"
+	start := sg1.AddNode("start")
+	check := sg1.AddNode("check")
+	done := f.AddNode("done")
+	other := sg2.AddNode("other")
+	start.Style = f.NodeStyle("ns1")
+	f.AddEdge(start, check)
+	f.AddEdge(check, done)
+	f.AddEdge(other, start)
+	fmt.Print(f.PruneUnreachable(check))
+	//Output:
+	//graph TB
+	//classDef ns1 stroke-width:1px
+	//
+	//   subgraph runbook
+	//     check["check"]
+	//   end
+	//   done["done"]
+	//
+	//   check --> done
+}
+
+// Finding the Nodes used by Edges
+func ExampleFlowchart_ReferencedNodeIDs() {
+	f := flowchart.NewFlowchart()
+	other := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	f.AddNode("isolated")
+	f.AddEdge(n2, n1)
+	f.AddEdge(n1, n2)
+	// oops, an Edge to a Node of another Flowchart
+	f.AddEdge(n1, other.AddNode("foreign"))
+	for _, id := range f.ReferencedNodeIDs() {
+		fmt.Println(id, f.GetNode(id) != nil)
+	}
+	//Output:
+	//foreign false
+	//n1 true
+	//n2 true
+}
+
+// Embedding the graph into an HTML page
+func ExampleFlowchart_Render() {
+	f := flowchart.NewFlowchart()
+	f.AddEdge(f.AddNode("a"), f.AddNode("b"))
+	tmpl := template.Must(template.New("page").Parse(
+		"<h1>{{.Data}}</h1>\n<pre class=\"mermaid\">\n{{.Diagram}}</pre>\n"))
+	page, _ := f.Render(tmpl, "My graph")
+	fmt.Print(string(page))
+	//Output:
+	//<h1>My graph</h1>
+	//<pre class="mermaid">
+	//graph TB
+	//
+	//   a[&#34;a&#34;]
+	//   b[&#34;b&#34;]
+	//
+	//   a --&gt; b
+	//</pre>
+}
+
+// Adding fully specified Edges in one call
+func ExampleFlowchart_AddEdgeShaped() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	e1 := f.AddEdgeLabeled(n1, n2, "calls")
+	e2 := f.AddEdgeShaped(n2, n1, flowchart.EShapeDottedArrow, "replies")
+	e3 := f.AddEdgeShaped(n2, n1, flowchart.EShapeThickLine, "")
+	fmt.Print(e1.String() + e2.String() + e3.String())
+	//Output:
+	//   n1 -->|"calls"| n2
+	//   n2 -.->|"replies"| n1
+	//   n2 === n1
+}
+
+// Controlling the layout via invisible Edges
+func ExampleFlowchart_AddInvisibleEdge() {
+	f := flowchart.NewFlowchart()
+	a, b, c := f.AddNode("a"), f.AddNode("b"), f.AddNode("c")
+	f.AddEdge(a, b)
+	f.AddInvisibleEdge(a, c).MinLength = 2 // c two ranks below a
+	fmt.Print(f)
+	fmt.Print(f.DOT())
+	//Output:
+	//graph TB
+	//
+	//   a["a"]
+	//   b["b"]
+	//   c["c"]
+	//
+	//   a --> b
+	//   a ~~~~ c
+	//digraph {
+	//   rankdir=TB;
+	//   "a" [label="a", shape=box];
+	//   "b" [label="b", shape=box];
+	//   "c" [label="c", shape=box];
+	//   "a" -> "b";
+	//   "a" -> "c" [style=invis];
+	//}
+}
+
+// Grouping a chain of Nodes into a new Subgraph
+func ExampleFlowchart_GroupIntoSubgraph() {
+	f := flowchart.NewFlowchart()
+	start := f.AddNode("start")
+	a := f.AddNode("a")
+	b := f.AddNode("b")
+	stop := f.AddNode("stop")
+	f.AddEdge(start, a)
+	f.AddEdge(a, b)
+	f.AddEdge(b, stop)
+	f.GroupIntoSubgraph("chain", "Chain", a, b)
+	// Nodes can't be moved twice
+	_, err := f.GroupIntoSubgraph("again", "Again", b)
+	fmt.Println(err)
+	fmt.Print(f)
+	//Output:
+	//GroupIntoSubgraph: Node b is already in Subgraph chain
+	//graph TB
+	//
+	//   start["start"]
+	//   subgraph Chain
+	//     a["a"]
+	//     b["b"]
+	//   end
+	//   stop["stop"]
+	//
+	//   start --> a
+	//   a --> b
+	//   b --> stop
+}
+
+// Walking the graph to write a custom exporter
+func ExampleFlowchart_WalkNodes() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	outer := f.AddSubgraph("outer")
+	n2 := outer.AddNode("n2")
+	outer.AddSubgraph("inner").AddNode("n3")
+	n4 := f.AddNode("n4")
+	f.AddEdge(n2, n4)
+	f.AddEdge(n1, n2)
+	f.WalkNodes(func(n *flowchart.Node, sg *flowchart.Subgraph) {
+		if sg == nil {
+			fmt.Println("node", n.ID())
+		} else {
+			fmt.Println("node", n.ID(), "in", sg.ID())
+		}
+	})
+	f.WalkEdges(func(e *flowchart.Edge) {
+		fmt.Println("edge", e.From.ID(), e.To.ID())
+	})
+	//Output:
+	//node n1
+	//node n2 in outer
+	//node n3 in inner
+	//node n4
+	//edge n2 n4
+	//edge n1 n2
+}
+
+// Grouping Nodes by a data attribute
+func ExampleFlowchart_GroupByMeta() {
+	f := flowchart.NewFlowchart()
+	for _, id := range []string{"api", "db", "web"} {
+		f.AddNode(id).Meta = map[string]string{"team": "backend"}
+	}
+	f.GetNode("web").Meta["team"] = "frontend"
+	f.AddNode("users")
+	f.AddEdge(f.GetNode("web"), f.GetNode("api"))
+	err := f.GroupByMeta("team", strings.ToUpper)
+	fmt.Println(err)
+	fmt.Print(f)
+	//Output:
+	//<nil>
+	//graph TB
+	//
+	//   subgraph BACKEND
+	//     api["api"]
+	//     db["db"]
+	//   end
+	//   subgraph FRONTEND
+	//     web["web"]
+	//   end
+	//   users["users"]
+	//
+	//   web --> api
+}
+
+func TestFlowchart_groupByMetaConflict(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("legacy")
+	sg.Title = "legacy"
+	n := sg.AddNode("n1")
+	n.Meta = map[string]string{"team": "backend"}
+	f.AddNode("n2").Meta = map[string]string{"team": "legacy"}
+	before := f.String()
+	if err := f.GroupByMeta("team", nil); err == nil {
+		t.Error("expected an error for a Node in another Subgraph")
+	}
+	if f.String() != before {
+		t.Errorf("expected no changes, got\n%s", f)
+	}
+	n.Meta["team"] = "legacy"
+	if err := f.GroupByMeta("team", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(f.GetSubgraph("legacy").String(), "n2[") {
+		t.Errorf("expected n2 in the existing Subgraph, got\n%s", f)
+	}
+}
+
+// Finding disjoint pieces of a graph
+func ExampleFlowchart_ConnectedComponents() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	sg := f.AddSubgraph("sg")
+	n2 := sg.AddNode("n2")
+	n3 := f.AddNode("n3")
+	n4 := f.AddNode("n4")
+	f.AddNode("n5")
+	f.AddEdge(n3, n1)
+	f.AddEdge(n4, n2)
+	for _, component := range f.ConnectedComponents() {
+		ids := []string{}
+		for _, n := range component {
+			ids = append(ids, n.ID())
+		}
+		fmt.Println(ids)
+	}
+	//Output:
+	//[n1 n3]
+	//[n2 n4]
+	//[n5]
+}
+
+// Locating the loops of a state machine
+func ExampleFlowchart_StronglyConnectedComponents() {
+	f := flowchart.NewFlowchart()
+	idle, running, paused, done := f.AddNode("idle"), f.AddNode("running"),
+		f.AddNode("paused"), f.AddNode("done")
+	f.AddEdge(idle, running)
+	f.AddEdge(running, paused)
+	f.AddEdge(paused, running)
+	f.AddEdge(running, done)
+	f.AddEdge(done, done)
+	for _, component := range f.StronglyConnectedComponents() {
+		ids := []string{}
+		for _, n := range component {
+			ids = append(ids, n.ID())
+		}
+		fmt.Println(ids)
+	}
+	//Output:
+	//[done]
+	//[idle]
+	//[paused running]
+}
+
+// Spotting hubs via the degree histograms
+func ExampleFlowchart_DegreeDistribution() {
+	f := flowchart.NewFlowchart()
+	hub := f.AddNode("hub")
+	for _, id := range []string{"a", "b", "c"} {
+		f.AddEdge(hub, f.AddNode(id))
+	}
+	f.AddEdge(f.GetNode("a"), f.GetNode("b"))
+	in, out := f.DegreeDistribution()
+	fmt.Println(in, out)
+	//Output:
+	//map[0:1 1:2 2:1] map[0:2 1:1 3:1]
+}
+
+// Finding Edges that render identically
+func ExampleFlowchart_DuplicateEdges() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	f.AddEdge(n1, n2).AddLines("uses")
+	f.AddEdge(n1, n2)
+	f.AddEdge(n1, n2).AddLines("uses")
+	f.AddEdge(n2, n1)
+	f.AddEdge(n1, n2).AddLines("uses")
+	f.AddEdge(n1, n2)
+	for _, group := range f.DuplicateEdges() {
+		ids := []int{}
+		for _, e := range group {
+			ids = append(ids, e.ID())
+		}
+		fmt.Println(ids)
+	}
+	//Output:
+	//[0 2 4]
+	//[1 5]
+}
+
+// Mapping mermaid error line numbers back to the producing items
+func ExampleFlowchart_WriteToMapped() {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("sg1")
+	sg.Title = "group"
+	n1 := sg.AddNode("n1")
+	n1.Style = f.NodeStyle("ns1")
+	f.AddEdge(n1, f.AddNode("n2"))
+	var b bytes.Buffer
+	var sm flowchart.SourceMap
+	f.WriteToMapped(&b, &sm)
+	for line := 1; line <= sm.Lines(); line++ {
+		switch item := sm.Item(line).(type) {
+		case *flowchart.Node:
+			fmt.Println(line, "node", item.ID())
+		case *flowchart.Edge:
+			fmt.Println(line, "edge", item.ID())
+		case *flowchart.Subgraph:
+			fmt.Println(line, "subgraph", item.ID())
+		case *flowchart.NodeStyle:
+			fmt.Println(line, "node style", item.ID())
+		default:
+			fmt.Println(line, "-")
+		}
+	}
+	fmt.Println(b.String() == f.String())
+	//Output:
+	//1 -
+	//2 node style ns1
+	//3 -
+	//4 subgraph sg1
+	//5 node n1
+	//6 node n1
+	//7 subgraph sg1
+	//8 node n2
+	//9 -
+	//10 edge 0
+	//true
+}
+
+// Guarding against runaway graphs
+func ExampleFlowchart_StringSafe() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	f.AddEdge(n1, n2)
+	f.MaxEdges = 1
+	_, err := f.StringSafe()
+	fmt.Println(err)
+	f.AddEdge(n2, n1)
+	_, err = f.StringSafe()
+	fmt.Println(err)
+	//Output:
+	//<nil>
+	//StringSafe: 2 Nodes and 2 Edges exceed the limits of 0 Nodes and 1 Edges
+}
+
+// Adjusting the layout density
+func ExampleFlowchart_spacing() {
+	f := flowchart.NewFlowchart()
+	f.AddNode("n1")
+	f.NodeSpacing = 80
+	f.RankSpacing = 120
+	fmt.Print(f)
+	f.RankSpacing = -1
+	_, err := f.StringSafe()
+	fmt.Println(err)
+	//Output:
+	//%%{init: {"flowchart":{"nodeSpacing":80,"rankSpacing":120}}}%%
+	//graph TB
+	//
+	//   n1["n1"]
+	//
+	//StringSafe: NodeSpacing 80 and RankSpacing -1 must not be negative
+}
+
+// Checking whether click interactions work with the SecurityLevel
+func ExampleFlowchart_Warnings() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n1.Link = "https://example.com"
+	fmt.Println(f.Warnings())
+	f.SecurityLevel = flowchart.SecuritySandbox
+	fmt.Println(f.Warnings())
+	fmt.Print(f)
+	//Output:
+	//[]
+	//[Node n1: click links don't work with securityLevel sandbox]
+	//%%{init: {"securityLevel":"sandbox"}}%%
+	//graph TB
+	//
+	//   n1["n1"]
+	//   click n1 "https://example.com" "https://example.com"
+	//
+}
+
+// Recording the provenance of a generated graph
+func ExampleFlowchart_SetMetadata() {
+	f := flowchart.NewFlowchart()
+	f.AddNode("n1")
+	f.SetMetadata("generator", "docs-job")
+	f.SetMetadata("generated", "2019-06-20T10:00:00Z")
+	c := f.Clone()
+	c.SetMetadata("generated", "2019-06-21T10:00:00Z")
+	// the metadata doesn't affect the comparison
+	fmt.Println(f.Equal(c), f.Fingerprint() == c.Fingerprint())
+	fmt.Print(f)
+	//Output:
+	//true true
+	//%% generated: 2019-06-20T10:00:00Z
+	//%% generator: docs-job
+	//graph TB
+	//
+	//   n1["n1"]
+	//
+}
+
+// Generating a legend from the NodeStyles
+func ExampleFlowchart_LegendSubgraph() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n1.Style = f.NodeStyle("service")
+	f.LegendSubgraph("Legend")
+	// NodeStyles defined later are part of the legend, too
+	f.AddNode("n2").Style = f.NodeStyle("database")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//classDef database stroke-width:1px
+	//classDef service stroke-width:1px
+	//
+	//   n1["n1"]
+	//   class n1 service
+	//   subgraph Legend
+	//     _legend_database["database"]
+	//   class _legend_database database
+	//     _legend_service["service"]
+	//   class _legend_service service
+	//   end
+	//   n2["n2"]
+	//   class n2 database
+}
+
+func TestFlowchart_LegendSubgraphSampleIDs(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.AddNode("_legend_x").Style = f.NodeStyle("x")
+	f.AddNode("n1").Style = f.NodeStyle("x_2")
+	f.LegendSubgraph("Legend")
+	text := f.String()
+	for _, want := range []string{`_legend_x["_legend_x"]`,
+		`_legend_x_2["x"]`, `_legend_x_5f_2_2["x_2"]`} {
+		assert(t, strings.Count(text, "  "+want+"\n") == 1,
+			"want one %s in:\n%s", want, text)
+	}
+}
+
+// Building a navigation chart for large diagrams
+func ExampleFlowchart_TableOfContents() {
+	f := flowchart.NewFlowchart()
+	f.AddNode("loose")
+	frontend := f.AddSubgraph("frontend")
+	frontend.Title = "Frontend"
+	frontend.AddSubgraph("nested")
+	f.AddSubgraph("backend")
+	toc := f.TableOfContents(func(sg *flowchart.Subgraph) string {
+		return "https://example.com/" + sg.ID() + ".html"
+	})
+	fmt.Print(toc)
+	//Output:
+	//graph TB
+	//
+	//   frontend["Frontend"]
+	//   click frontend "https://example.com/frontend.html" "https://example.com/frontend.html"
+	//   backend["backend"]
+	//   click backend "https://example.com/backend.html" "https://example.com/backend.html"
+}
+
+// Cleaning up parallel Edges
+func ExampleFlowchart_MergeParallelEdges() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	f.AddEdge(n1, n2).AddLines("http")
+	f.AddEdge(n2, n1).AddLines("callback")
+	f.AddEdge(n1, n2)
+	e := f.AddEdge(n1, n2)
+	e.AddLines("grpc")
+	e.Shape = flowchart.EShapeThickArrow
+	fmt.Println(f.MergeParallelEdges(", "))
+	for _, e := range f.ListEdges() {
+		fmt.Printf("%d:%s", e.ID(), e)
+	}
+	//Output:
+	//2
+	//0:  n1 ==>|"http, grpc"| n2
+	//1:  n2 -->|"callback"| n1
+}
+
+func TestFlowchart_MergeParallelEdges(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	n1, n2 := f.AddNode("n1"), f.AddNode("n2")
+	kept := f.AddEdge(n1, n2)
+	kept.AddLines("a1", "a2")
+	x := f.AddEdge(n1, n2)
+	x.AddLines("b1", "b2")
+	x.SetName("x")
+	x.SetAnimated(true)
+	x.SetClass("c")
+	x.SetTooltip("tip")
+	x.SetComment("source")
+	f.AddEdge(n1, n2).SetDirection(false) // rendered n2 --> n1
+	f.AddEdge(n2, n1).SetDirection(false) // rendered n1 --> n2
+	if merged := f.MergeParallelEdges(" / "); merged != 2 {
+		t.Fatalf("expected 2 merged Edges, got %d", merged)
+	}
+	edges := f.ListEdges()
+	assert(t, len(edges) == 2 && edges[0] == kept && !edges[1].Forward(),
+		"unexpected Edges %v", edges)
+	assert(t, strings.Join(kept.Text, "|") == "a1|a2 / b1|b2",
+		"the lines must be kept: %q", kept.Text)
+	assert(t, kept.Name() == "x" && kept.Animated() && kept.Class() == "c" &&
+		kept.Tooltip() == "tip" && kept.Comment() == "source",
+		"the settings of merged Edges must be carried over")
+}
+
+// Enforcing a label length budget
+func ExampleFlowchart_LabelsExceeding() {
+	f := flowchart.NewFlowchart()
+	f.AddNode("short").AddLines("fits", "fits, too")
+	f.AddNode("long").AddLines("fits", "this line is too long")
+	f.AddNode("a_very_long_id_without_text")
+	for _, n := range f.LabelsExceeding(12) {
+		fmt.Println(n.ID())
+	}
+	//Output:
+	//a_very_long_id_without_text
+	//long
+}
+
+// Rendering a placeholder instead of a blank graph
+func ExampleFlowchart_EmptyPlaceholder() {
+	f := flowchart.NewFlowchart()
+	f.EmptyPlaceholder = "(no data)"
+	fmt.Print(f)
+	f.AddNode("n1")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   empty["#40;no data#41;"]
+	//
+	//graph TB
+	//
+	//   n1["n1"]
+}
+
+// Stable Node IDs derived from labels
+func ExampleFlowchart_AddNodeByLabel() {
+	f := flowchart.NewFlowchart()
+	api := f.AddNodeByLabel("API")
+	db := f.AddNodeByLabel("Database")
+	f.AddEdge(api, db)
+	fmt.Println(f.AddNodeByLabel("API") == api)
+	fmt.Print(f)
+	//Output:
+	//true
+	//graph TB
+	//
+	//   d93d10ff["API"]
+	//   61074f1c["Database"]
+	//
+	//   d93d10ff --> 61074f1c
+}
+
+func TestFlowchart_addNodeByLabelCollision(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	n := f.AddNodeByLabel("API")
+	// take the ID of "API" for another label
+	f2 := flowchart.NewFlowchart()
+	taken := f2.AddNode(n.ID())
+	taken.AddLines("other")
+	x := f2.AddNodeByLabel("API")
+	if x == taken || x.ID() != n.ID()+"_2" {
+		t.Errorf("expected a suffixed ID, got %s", x.ID())
+	}
+	if f2.AddNodeByLabel("API") != x {
+		t.Error("expected the suffixed Node to be reused")
+	}
+}
+
+// Merging Nodes that represent the same entity
+func ExampleFlowchart_DedupeNodesByLabel() {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("billing")
+	sg.Title = "billing"
+	billingDB := sg.AddNode("billing_db")
+	billingDB.AddLines("Postgres")
+	billingDB.Shape = flowchart.NShapeCylinder
+	db := f.AddNode("users_db")
+	db.AddLines("Postgres")
+	db.Shape = flowchart.NShapeCylinder
+	f.AddEdge(f.AddNode("users"), db)
+	f.AddEdge(sg.AddNode("invoices"), billingDB)
+	fmt.Println(f.DedupeNodesByLabel())
+	fmt.Print(f)
+	//Output:
+	//1
+	//graph TB
+	//
+	//   subgraph billing
+	//     billing_db[("Postgres")]
+	//     invoices["invoices"]
+	//   end
+	//   users["users"]
+	//
+	//   users --> billing_db
+	//   invoices --> billing_db
+}
+
+// Rendering Edges independent of the order they were added in
+func ExampleFlowchart_SortEdges() {
+	f := flowchart.NewFlowchart()
+	a, b, c := f.AddNode("a"), f.AddNode("b"), f.AddNode("c")
+	f.AddEdge(b, c)
+	e := f.AddEdge(a, c)
+	e.Style = f.EdgeStyle("es1")
+	e.Style.Stroke = "#f00"
+	f.AddEdge(a, b)
+	f.CanonicalEdgeOrder = true
+	fmt.Print(f)
+	// the IDs stay unchanged unless the Edges are sorted in place
+	fmt.Print(f.GetEdge(0))
+	f.SortEdges()
+	fmt.Print(f.GetEdge(0))
+	//Output:
+	//graph TB
+	//
+	//   a["a"]
+	//   b["b"]
+	//   c["c"]
+	//
+	//   a --> b
+	//   a --> c
+	//linkStyle 1 stroke:#f00
+	//   b --> c
+	//   b --> c
+	//   a --> b
+}
+
+// Rendering the structure without any styling
+func ExampleFlowchart_StringStructureOnly() {
+	f := flowchart.NewFlowchart()
+	f.DefaultEdgeStyle = f.EdgeStyle("es1")
+	n1 := f.AddNode("n1")
+	n1.Style = f.NodeStyle("ns1")
+	n1.SetMinWidth(100)
+	e := f.AddEdge(n1, f.AddNode("n2"))
+	e.Style = f.EdgeStyle("es2")
+	fmt.Print(f.StringStructureOnly())
+	//Output:
+	//graph TB
+	//
+	//   n1["n1"]
+	//   n2["n2"]
+	//
+	//   n1 --> n2
+}
+
+func TestFlowchart_fingerprint(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.AddNode("n1")
+	c := f.Clone()
+	assert(t, f.Equal(c) && !f.Equal(nil))
+	c.AddNode("n2")
+	assert(t, !f.Equal(c), "Fingerprint must change with the structure")
+	f.SetMetadata("key", "value")
+	f.SetMetadata("key", "")
+	assert(t, len(f.MetadataKeys()) == 0 && f.Metadata("key") == "")
+}
+
+func TestFlowchart_SetMetadataLineBreaks(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.SetMetadata("a\nn1 --> n2\r\nb\rc", "d\ne")
+	text := f.String()
+	want := "%% a n1 --> n2 b c: d e\n"
+	assert(t, strings.HasPrefix(text, want), "want %q in:\n%s", want, text)
+}
+
+// Undoing changes via Snapshots
+func ExampleFlowchart_Snapshot() {
+	f := flowchart.NewFlowchart()
+	f.AddNode("n1")
+	before := f.Snapshot()
+	f.AddSubgraph("sg1").AddNode("n2")
+	f.Direction = flowchart.DirectionLeftRight
+	f.Restore(before)
+	// the restored state belongs to f
+	sg := f.AddSubgraph("sg2")
+	sg.Title = "sg2"
+	sg.AddNode("n3").Shape = flowchart.NShapeCircle
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   n1["n1"]
+	//   subgraph sg2
+	//     n3(("n3"))
+	//   end
+	//
+}
+
+func TestFlowchart_restore(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	f.AddNode("n1").SetMinWidth(10)
+	snapshot := f.Snapshot()
+	f.AddNode("n2")
+	view := f.String()
+	f.Restore(snapshot)
+	assert(t, f.GetNode("n2") == nil && f.GetNode("n1") != nil)
+	assert(t, f.GetNode("n1").String() == "  n1[\"n1\"]\n  n1@{ w: 10 }\n",
+		"restored Node lost its Flowchart: %q", f.GetNode("n1").String())
+	f.Restore(snapshot)
+	assert(t, f.String() != view && len(f.ListNodes()) == 1)
+	var empty flowchart.Snapshot
+	f.Restore(empty)
+	assert(t, len(f.ListNodes()) == 0)
+}
+
+func assert(t *testing.T, condition bool, msg ...interface{}) {
+	if !condition {
+		if len(msg) > 0 {
+			t.Errorf(msg[0].(string), msg[1:]...)
+		} else {
+			t.Fail()
+		}
+	}
+}
+
+func TestFlowchart_clone(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("sg")
+	n1 := sg.AddNode("n1")
+	n1.AddLines("text")
+	n1.Style = f.NodeStyle("ns")
+	e := f.AddEdge(n1, f.AddNode("n2"))
+	e.Style = f.EdgeStyle("es")
+	c := f.Clone()
+	assert(t, c.String() == f.String())
+	cn1 := c.GetNode("n1")
+	assert(t, cn1 != n1)
+	assert(t, cn1.Style == c.NodeStyle("ns"))
+	assert(t, cn1.Style != n1.Style)
+	assert(t, c.GetEdge(0).From == cn1)
+	assert(t, c.GetEdge(0).Style == c.EdgeStyle("es"))
+	assert(t, c.GetSubgraph("sg").Flowchart() == c)
+	cn1.Text[0] = "changed"
+	c.NodeStyle("ns").Fill = flowchart.ColorRed
+	assert(t, n1.Text[0] == "text")
+	assert(t, f.NodeStyle("ns").Fill == "")
+}
+
+// linkStyleTargets maps the labels of the rendered edge lines to the styles
+// that linkStyle lines assign to them by index.
+func linkStyleTargets(t *testing.T, rendered string) map[string]string {
+	edgeLine := regexp.MustCompile(`^\s+\S+ [-=.>]+\|"([^"]*)"\| \S+$`)
+	linkStyle := regexp.MustCompile(`^\s*linkStyle (\d+) (.*)$`)
+	labels := []string{}
+	styles := map[int]string{}
+	for _, line := range strings.Split(rendered, "\n") {
+		if m := edgeLine.FindStringSubmatch(line); m != nil {
+			labels = append(labels, m[1])
+		} else if m := linkStyle.FindStringSubmatch(line); m != nil {
+			i, _ := strconv.Atoi(m[1])
+			styles[i] = m[2]
+		}
+	}
+	targets := map[string]string{}
+	for i, style := range styles {
+		if i >= len(labels) {
+			t.Fatalf("linkStyle %d addresses no edge in\n%s", i, rendered)
+		}
+		targets[labels[i]] = style
+	}
+	return targets
+}
+
+func TestFlowchart_linkStyleIndices(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	red, blue := f.EdgeStyle("red"), f.EdgeStyle("blue")
+	red.Stroke, blue.Stroke = "#f00", "#00f"
+	b1, b2 := f.AddNodeByLabel("B"), f.AddNodeByLabel("B2")
+	b2.Text = []string{"B"} // merged into b1 by DedupeNodesByLabel
+	c, a := f.AddNode("c"), f.AddNode("a")
+	f.AddEdge(c, a).AddLines("c-a")
+	e := f.AddEdge(c, b2)
+	e.AddLines("c-b")
+	e.Style = red
+	// parallel to c-b after deduplication
+	f.AddEdge(c, b1).AddLines("c-b too")
+	e = f.AddEdge(b1, a)
+	e.AddLines("b-a")
+	e.Style = blue
+	e = f.AddEdge(a, c)
+	e.AddLines("a-c")
+	e.Style = red
+	check := func(step string) {
+		t.Helper()
+		want := map[string]string{}
+		for _, e := range f.ListEdges() {
+			if e.Style != nil {
+				want[strings.Join(e.Text, " ")] = "stroke:" + string(e.Style.Stroke)
+			}
+		}
+		got := linkStyleTargets(t, f.String())
+		for label, style := range want {
+			if got[label] != style {
+				t.Errorf("%s: edge %s got style %q, want %q in\n%s", step, label,
+					got[label], style, f)
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d styled edges, want %d", step, len(got), len(want))
+		}
+	}
+	check("insertion order")
+	f.CanonicalEdgeOrder = true
+	check("canonical order")
+	f.DedupeNodesByLabel()
+	f.MergeParallelEdges(" ")
+	check("merged")
+	f.CanonicalEdgeOrder = false
+	f.SortEdges()
+	check("sorted")
+	f = f.PruneUnreachable(a)
+	check("pruned copy")
+}
+
+// Rendering a pipeline built back to front in the order of its flow
+func ExampleFlowchart_TopologicalOutput() {
+	f := flowchart.NewFlowchart()
+	f.TopologicalOutput = true
+	deploy := f.AddNode("deploy")
+	test := f.AddNode("test")
+	build := f.AddNode("build")
+	f.AddEdge(test, deploy)
+	f.AddEdge(build, test)
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   build["build"]
+	//   test["test"]
+	//   deploy["deploy"]
+	//
+	//   build --> test
+	//   test --> deploy
+}
+
+func TestFlowchart_topologicalOutputCycle(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.TopologicalOutput = true
+	b, a := f.AddNode("b"), f.AddNode("a")
+	f.AddEdge(a, b)
+	if got := f.String(); strings.Index(got, "  a") > strings.Index(got, "  b") {
+		t.Errorf("expected a before b in\n%s", got)
+	}
+	if len(f.Warnings()) != 0 {
+		t.Errorf("unexpected warnings %v", f.Warnings())
+	}
+	f.AddEdge(b, a)
+	if got := f.String(); strings.Index(got, "  b") > strings.Index(got, "  a") {
+		t.Errorf("expected the insertion order in\n%s", got)
+	}
+	if len(f.Warnings()) != 1 {
+		t.Errorf("expected a warning about the cycle, got %v", f.Warnings())
+	}
+}
+
+func TestFlowchart_topologicalOutputForeignNodes(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.TopologicalOutput = true
+	foreign := flowchart.NewFlowchart().AddNode("x")
+	a, b := f.AddNode("a"), f.AddNode("b")
+	f.AddEdge(foreign, b)
+	f.AddEdge(a, b)
+	if len(f.Warnings()) != 0 {
+		t.Errorf("unexpected warnings %v", f.Warnings())
+	}
+	if path, err := f.LongestPath(); err != nil || len(path) != 2 {
+		t.Errorf("expected the path a, b, got %v, %v", path, err)
+	}
+}
+
+func BenchmarkFlowchart_TopologicalOutput(b *testing.B) {
+	f := flowchart.NewFlowchart()
+	f.TopologicalOutput = true
+	prev := f.AddNode("n0")
+	for i := 1; i < 8000; i++ {
+		n := f.AddNode("n" + strconv.Itoa(i))
+		f.AddEdge(prev, n)
+		prev = n
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = f.String()
+	}
+}
+
+func BenchmarkFlowchart_LiveURL(b *testing.B) {
+	f := flowchart.NewFlowchart()
+	prev := f.AddNode("n0")
+	for i := 1; i < 50; i++ {
+		n := f.AddNode("n" + strconv.Itoa(i))
+		f.AddEdge(prev, n)
+		prev = n
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f.LiveURL()
+	}
+}
+
+// Labeling Edges with the latencies stored as their Weight
+func ExampleFlowchart_LabelEdgesFromWeight() {
+	f := flowchart.NewFlowchart()
+	client, api, db := f.AddNode("client"), f.AddNode("api"), f.AddNode("db")
+	f.AddEdge(client, api).Weight = 12.34
+	f.AddEdge(api, db).Weight = 3.5
+	f.AddEdge(api, api) // no Weight, no label
+	f.LabelEdgesFromWeight(func(weight float64) string {
+		return fmt.Sprintf("%.1fms", weight)
+	})
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   client["client"]
+	//   api["api"]
+	//   db["db"]
+	//
+	//   client -->|"12.3ms"| api
+	//   api -->|"3.5ms"| db
+	//   api --> api
+}
+
+// Renaming generated Node IDs that happen to be mermaid keywords
+func ExampleFlowchart_EscapeReservedIDs() {
+	f := flowchart.NewFlowchart()
+	start := f.AddNode("start")
+	end := f.AddNode("end")
+	f.AddNode("end_") // the first safe variant is taken already
+	f.AddEdge(start, end)
+	fmt.Println(f.EscapeReservedIDs())
+	fmt.Print(f)
+	//Output:
+	//map[end:end_2]
+	//graph TB
+	//
+	//   start["start"]
+	//   end_2["end"]
+	//   end_["end_"]
+	//
+	//   start --> end_2
+}
+
+func TestFlowchart_EscapeReservedIDs(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("class")
+	sg.AddNode("style").AddLines("Style")
+	f.AddNode("class_")
+	renamed := f.EscapeReservedIDs()
+	if len(renamed) != 2 || renamed["style"] != "style_" ||
+		renamed["class"] != "class_2" {
+		t.Fatalf("unexpected renames %v", renamed)
+	}
+	if f.GetNode("style") != nil || f.GetNode("style_") == nil ||
+		f.GetSubgraph("class_2") != sg || f.GetNode("class_") == nil {
+		t.Error("lookups don't match the renames")
+	}
+	if renamed := f.EscapeReservedIDs(); len(renamed) != 0 {
+		t.Error("expected nothing left to rename")
+	}
+	// a Node and a Subgraph sharing a keyword ID
+	g := flowchart.NewFlowchart()
+	g.AddSubgraph("end").AddNode("x")
+	n := g.AddNode("end")
+	renamed = g.EscapeReservedIDs()
+	if len(renamed) != 1 || renamed["end"] != "end_" ||
+		g.GetNode("end_") != n || g.GetSubgraph("end_") == nil {
+		t.Errorf("unexpected renames %v", renamed)
+	}
+}
+
+// Splitting a large Flowchart into an index and one diagram per domain
+func ExampleFlowchart_SplitBySubgraph() {
+	f := flowchart.NewFlowchart()
+	shop := f.AddSubgraph("shop")
+	shop.Title = "Shop"
+	cart, checkout := shop.AddNode("cart"), shop.AddNode("checkout")
+	f.AddEdge(cart, checkout)
+	billing := f.AddSubgraph("billing")
+	billing.Title = "Billing"
+	invoice := billing.AddNode("invoice")
+	f.AddEdge(checkout, invoice)
+	f.AddEdge(cart, invoice)
+	charts := f.SplitBySubgraph()
+	fmt.Print(charts["index"])
+	fmt.Print(charts["shop"])
+	//Output:
+	//graph TB
+	//
+	//   shop["Shop"]
+	//   click shop "shop.mmd" "shop.mmd"
+	//   billing["Billing"]
+	//   click billing "billing.mmd" "billing.mmd"
+	//
+	//   shop --> billing
+	//graph TB
+	//
+	//   cart["cart"]
+	//   checkout["checkout"]
+	//
+	//   cart --> checkout
+}
+
+// Finding the longest chain of steps of a pipeline
+func ExampleFlowchart_LongestPath() {
+	f := flowchart.NewFlowchart()
+	checkout, build := f.AddNode("checkout"), f.AddNode("build")
+	lint, test, deploy := f.AddNode("lint"), f.AddNode("test"), f.AddNode("deploy")
+	f.AddEdge(checkout, lint)
+	f.AddEdge(checkout, build)
+	f.AddEdge(build, test)
+	f.AddEdge(lint, deploy)
+	f.AddEdge(test, deploy)
+	show := func(path []*flowchart.Node) {
+		ids := []string{}
+		for _, n := range path {
+			ids = append(ids, n.ID())
+		}
+		fmt.Println(strings.Join(ids, " -> "))
+	}
+	path, _ := f.LongestPath()
+	show(path)
+	// with weights the slow lint step dominates
+	for _, e := range f.ListEdges() {
+		e.Weight = 1
+	}
+	f.GetEdge(3).Weight = 10
+	path, _ = f.LongestPath()
+	show(path)
+	//Output:
+	//checkout -> build -> test -> deploy
+	//checkout -> lint -> deploy
+}
+
+func TestFlowchart_LongestPath(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	if path, err := f.LongestPath(); err != nil || len(path) != 0 {
+		t.Errorf("expected an empty path, got %v, %v", path, err)
+	}
+	a, b, c := f.AddNode("a"), f.AddNode("b"), f.AddNode("c")
+	if path, _ := f.LongestPath(); len(path) != 1 || path[0] != a {
+		t.Errorf("expected the first Node on ties, got %v", path)
+	}
+	f.AddEdge(c, b)
+	// rendered b --> a, but the path follows From and To
+	f.AddEdge(a, b).SetDirection(false)
+	if path, _ := f.LongestPath(); len(path) != 2 || path[0] != a || path[1] != b {
+		t.Errorf("expected a, b, got %v", path)
+	}
+	f.AddEdge(b, a)
+	if _, err := f.LongestPath(); err == nil {
+		t.Error("expected an error for a cycle")
+	}
 }
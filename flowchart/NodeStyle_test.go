@@ -3,7 +3,7 @@ package flowchart_test
 import (
 	"fmt"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with NodeStyles
@@ -30,10 +30,11 @@ func ExampleNodeStyle() {
 	//graph TB
 	//classDef ns1 stroke-width:1px
 	//classDef ns2 fill:#ff0,stroke:#00f,stroke-width:2px,stroke-dasharray:5px,font-size:20px
-	//n1["n1"]
-	//class n1 ns1
-	//n2["n2"]
-	//class n2 ns2
+	//
+	//   n1["n1"]
+	//   class n1 ns1
+	//   n2["n2"]
+	//   class n2 ns2
 }
 
 // Accessing the readonly fields of a NodeStyle
@@ -2,8 +2,9 @@ package flowchart_test
 
 import (
 	"fmt"
+	"testing"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with Nodes
@@ -28,10 +29,61 @@ func ExampleNode() {
 	//Output:
 	//graph TB
 	//classDef ns1 fill:#0ff
-	//n1("first line<br/>second line<br/>third line")
-	//n2["n2"]
-	//class n2 ns1
-	//click n2 "http://www.example.com" "tooltip"
+	//
+	//   n1("first line<br/>second line<br/>third line")
+	//   n2["n2"]
+	//   class n2 ns1
+	//   click n2 "http://www.example.com" "tooltip"
+}
+
+// Wiring Node clicks to JavaScript functions of the embedding page
+func ExampleNode_SetCallback() {
+	f := flowchart.NewFlowchart()
+	n := f.AddNode("svc")
+	n.SetCallback("showDetails", "svc", "prod, eu")
+	n.Tooltip = "details"
+	// names that would inject mermaid statements are rejected
+	fmt.Println(n.SetCallback("alert(1)\nclick svc"))
+	fmt.Println(f.Warnings())
+	f.SecurityLevel = flowchart.SecurityLoose
+	fmt.Println(f.Warnings())
+	fmt.Print(n)
+	//Output:
+	//SetCallback: invalid function name "alert(1)\nclick svc"
+	//[Node svc: click callbacks don't work with securityLevel strict]
+	//[]
+	//   svc["svc"]
+	//   click svc call showDetails("svc", "prod, eu") "details"
+}
+
+// Showing hover text on Nodes
+func ExampleNode_tooltip() {
+	f := flowchart.NewFlowchart()
+	docs := f.AddNode("docs")
+	docs.Link = "https://example.com/docs"
+	docs.Tooltip = `Read the "getting started" guide`
+	f.AddNode("todo").Tooltip = "not rendered without a click"
+	fmt.Println(f.Warnings())
+	fmt.Print(f)
+	//Output:
+	//[Node todo: the Tooltip needs a Link or callback to be rendered]
+	//graph TB
+	//
+	//   docs["docs"]
+	//   click docs "https://example.com/docs" "Read the 'getting started' guide"
+	//   todo["todo"]
+}
+
+// Opening a Node's link in a new tab
+func ExampleNode_linkTarget() {
+	f := flowchart.NewFlowchart()
+	n := f.AddNode("docs")
+	n.Link = "https://example.com/docs"
+	n.LinkTarget = flowchart.LinkTargetBlank
+	fmt.Print(n)
+	//Output:
+	//docs["docs"]
+	//   click docs "https://example.com/docs" "https://example.com/docs" _blank
 }
 
 // Accessing the readonly fields of a Node
@@ -42,3 +94,217 @@ func ExampleNode_privateFields() {
 	fmt.Println(n1.ID())
 	//Output: this_is_my_id
 }
+
+// Setting the shape of a Node safely
+func ExampleNode_SetShape() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n2 := f.AddNode("n2")
+	// SetShape validates the given shape
+	err := n1.SetShape(flowchart.NShapeRhombus)
+	fmt.Println(err)
+	err = n1.SetShape("garbage")
+	fmt.Println(err)
+	// invalid shapes assigned directly render as NShapeRect
+	n2.Shape = "garbage"
+	fmt.Println(n1.EffectiveShape() == flowchart.NShapeRhombus)
+	fmt.Println(n2.EffectiveShape() == flowchart.NShapeRect)
+	fmt.Print(n2)
+	//Output:
+	//<nil>
+	//SetShape: unknown shape "garbage"
+	//true
+	//true
+	//   n2["n2"]
+}
+
+// Using the stadium, hexagon, parallelogram and trapezoid shapes
+func ExampleNode_shapes() {
+	f := flowchart.NewFlowchart()
+	for i, shape := range []flowchart.Node{{Shape: flowchart.NShapeStadium},
+		{Shape: flowchart.NShapeHexagon}, {Shape: flowchart.NShapeParallelogram},
+		{Shape: flowchart.NShapeParallelogramAlt},
+		{Shape: flowchart.NShapeTrapezoid}, {Shape: flowchart.NShapeTrapezoidAlt}} {
+		f.AddNode(fmt.Sprintf("n%d", i)).SetShape(shape.Shape)
+	}
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   n0(["n0"])
+	//   n1{{"n1"}}
+	//   n2[/"n2"/]
+	//   n3[\"n3"\]
+	//   n4[/"n4"\]
+	//   n5[\"n5"/]
+}
+
+// Using the shapes of mermaid 11 with a fallback for older versions
+func ExampleNode_SetExtendedShape() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	n := f.AddNode("report")
+	n.AddLines(`Weekly "KPI" report`)
+	n.Shape = flowchart.NShapeRect // fallback
+	fmt.Println(n.SetExtendedShape(flowchart.XShapeDoc))
+	fmt.Println(n.SetExtendedShape("blob"))
+	fmt.Print(n)
+	f.MermaidVersion = flowchart.MermaidV10
+	fmt.Print(n)
+	//Output:
+	//<nil>
+	//SetExtendedShape: unknown shape "blob"
+	//   report@{ shape: doc, label: "Weekly #quot;KPI#quot; report" }
+	//   report["Weekly #quot;KPI#quot; report"]
+}
+
+// Embedding an icon as image Node with a fallback for older versions
+func ExampleNode_image() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	n := f.AddNode("db")
+	n.AddLines("Database")
+	n.ImageURL = "https://example.com/db.svg"
+	n.ImageWidth, n.ImageHeight = 60, 60
+	n.LabelPosition = flowchart.LabelTop
+	n.Shape = flowchart.NShapeCircle // fallback
+	fmt.Print(n)
+	f.MermaidVersion = flowchart.MermaidV10
+	fmt.Print(n)
+	//Output:
+	//db@{ img: "https://example.com/db.svg", label: "Database", pos: "t", w: 60, h: 60 }
+	//   db(("Database"))
+}
+
+// Drawing architecture diagrams with icon packs
+func ExampleNode_icon() {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	f.IconPacks = []string{"aws"}
+	vm := f.AddNode("vm")
+	vm.AddLines("EC2")
+	vm.Icon = "aws:ec2"
+	vm.IconForm = flowchart.IconFormSquare
+	db := f.AddNode("db")
+	db.Icon = "logos:postgresql"
+	db.LabelPosition = flowchart.LabelTop
+	f.AddEdge(vm, db)
+	fmt.Print(f)
+	for _, w := range f.Warnings() {
+		fmt.Println(w)
+	}
+	//Output:
+	//graph TB
+	//
+	//   vm@{ icon: "aws:ec2", form: "square", label: "EC2" }
+	//   db@{ icon: "logos:postgresql", label: "db", pos: "t" }
+	//
+	//   vm --> db
+	//Node db: the icon pack "logos" is not in IconPacks
+}
+
+// Formatting labels via markdown strings
+func ExampleNode_markdownText() {
+	f := flowchart.NewFlowchart()
+	n := f.AddNode("n")
+	n.AddLines("**Important**", "a *very* long label wrapped by mermaid")
+	n.MarkdownText = true
+	e := f.AddEdge(n, f.AddNode("m"))
+	e.AddLines("uses `code`")
+	e.MarkdownText = true
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   n["`**Important**
+	//a *very* long label wrapped by mermaid`"]
+	//   m["m"]
+	//
+	//   n -->|"`uses #96;code#96;`"| m
+}
+
+// Setting multi-line labels without hand-crafted <br/>'s
+func ExampleNode_SetLines() {
+	f := flowchart.NewFlowchart()
+	n := f.AddNode("n")
+	n.SetLines("Title\nSubtitle", "Footer")
+	fmt.Print(n)
+	n.MarkdownText = true
+	fmt.Print(n)
+	n.SetLines()
+	fmt.Print(n)
+	//Output:
+	//n["Title<br/>Subtitle<br/>Footer"]
+	//   n["`Title
+	//Subtitle
+	//Footer`"]
+	//   n["`n`"]
+}
+
+// Recording the provenance of Nodes and Edges
+func ExampleNode_SetComment() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n1.SetComment("record 4711")
+	e := f.AddEdge(n1, f.AddNode("n2"))
+	e.SetComment("relation 0815\nimported from CMDB")
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   %% record 4711
+	//   n1["n1"]
+	//   n2["n2"]
+	//
+	//   %% relation 0815
+	//   %% imported from CMDB
+	//   n1 --> n2
+}
+
+// Normalizing the size of Nodes
+func ExampleNode_SetMinWidth() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n1.SetMinWidth(120)
+	n1.SetMinHeight(40)
+	n2 := f.AddNode("n2")
+	n2.SetMinWidth(120)
+	// older mermaid versions get a style line as fallback
+	fmt.Print(n1.String() + n2.String())
+	// mermaid 11 supports node metadata
+	f.MermaidVersion = flowchart.MermaidV11
+	fmt.Print(n1.String() + n2.String())
+	//Output:
+	//   n1["n1"]
+	//   style n1 min-width:120px,min-height:40px
+	//   n2["n2"]
+	//   style n2 min-width:120px
+	//   n1["n1"]
+	//   n1@{ w: 120, h: 40 }
+	//   n2["n2"]
+	//   n2@{ w: 120 }
+}
+
+func TestNode_delimiterLabels(t *testing.T) {
+	// labels containing the delimiters of their shape must not break it
+	for _, tc := range []struct {
+		proto    flowchart.Node
+		label    string
+		expected string
+	}{
+		{flowchart.Node{Shape: flowchart.NShapeSubroutine}, "[[nested]]",
+			`  n[["#91;#91;nested#93;#93;"]]` + "\n"},
+		{flowchart.Node{Shape: flowchart.NShapeCylinder}, "[(db)]",
+			`  n[("#91;#40;db#41;#93;")]` + "\n"},
+		{flowchart.Node{Shape: flowchart.NShapeDoubleCircle}, "(((x)))",
+			`  n((("#40;#40;#40;x#41;#41;#41;")))` + "\n"},
+	} {
+		n := flowchart.NewFlowchart().AddNode("n")
+		n.AddLines(tc.label)
+		if err := n.SetShape(tc.proto.Shape); err != nil {
+			t.Fatal(err)
+		}
+		assert(t, n.String() == tc.expected, "expected %q, got %q", tc.expected,
+			n.String())
+	}
+}
@@ -0,0 +1,250 @@
+package flowchart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of items ParseStream emits.
+const (
+	ParsedNode     = "node"     // item is a *Node
+	ParsedEdge     = "edge"     // item is an *Edge
+	ParsedSubgraph = "subgraph" // item is a *Subgraph that was opened
+	ParsedEnd      = "end"      // item is the *Subgraph that was closed
+)
+
+// regular expressions for the supported statements
+var (
+	parseNode = regexp.MustCompile(`^([A-Za-z0-9_-]+)(.*)$`)
+	parseEdge = regexp.MustCompile(
+		`^([A-Za-z0-9_-]+)\s+(?:([A-Za-z0-9_-]+)@)?([-.=<>ox~]+)(?:\|"([^"]*)"\|)?\s+([A-Za-z0-9_-]+)$`)
+	parseDotted = regexp.MustCompile(`^-(\.+)-(>?)$`)
+	parseSolid  = regexp.MustCompile(`^(-{2,}|={2,})([->=])$`)
+	parseEnded  = regexp.MustCompile(`^([<ox]?)(-{2,})([>ox])$`)
+	parseHidden = regexp.MustCompile(`^~{3,}$`)
+	parseEntity = regexp.MustCompile(`#(quot|[0-9]+);`)
+	parseTitled = regexp.MustCompile(`^([A-Za-z0-9_-]+)\["(.*)"\]$`)
+	parseData   = regexp.MustCompile(`^([A-Za-z0-9_-]+)@\{ (.*) \}$`)
+	parseField  = regexp.MustCompile(`([a-z]+): ("[^"]*"|[0-9]+|[a-z-]+)`)
+)
+
+// statements that don't define items and are skipped by ParseStream
+var parseSkipped = []string{"%%", "graph ", "flowchart ", "classDef ",
+	"class ", "click ", "style ", "linkStyle ", "direction "}
+
+// ParseStream reads mermaid flowchart code from r line by line and calls fn
+// for each item as soon as it is parsed, so even huge files can be processed
+// without holding the whole graph in memory. The kind passed to fn is one of
+// ParsedNode, ParsedEdge, ParsedSubgraph and ParsedEnd. Subgraphs are emitted
+// when they are opened and once more when they are closed, their ID is their
+// title (or the ID of escaped titles, see Subgraph's String) and their Parent
+// is set. Edges get IDs by their order and point to
+// fresh Nodes carrying only the IDs of their endpoints. The emitted items
+// don't belong to any Flowchart. ParseStream understands the statements String
+// renders: Node definitions in all shapes (including extended shapes, see
+// SetExtendedShape, image and icon Nodes), Edges with optional label, length
+// and name, subgraph and end. Styles, clicks, comments, metadata and other
+// directives are skipped. Node IDs and texts are taken as they are rendered,
+// except that label escapes are reverted and <br/> splits the text lines.
+// Parsing stops at the first error returned by fn, which is returned then.
+// Unsupported statements cause an error naming the line.
+func ParseStream(r io.Reader, fn func(kind string, item interface{}) error) (err error) {
+	reader := bufio.NewReader(r)
+	stack := []*Subgraph{}
+	edges := 0
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		line = strings.TrimSpace(line)
+		kind, item, err := parseStatement(line, stack, edges)
+		if err != nil {
+			return fmt.Errorf("ParseStream: line %d: %s", lineNo, err)
+		}
+		switch kind {
+		case ParsedSubgraph:
+			stack = append(stack, item.(*Subgraph))
+		case ParsedEnd:
+			stack = stack[:len(stack)-1]
+		case ParsedEdge:
+			edges++
+		}
+		if kind != "" {
+			if err := fn(kind, item); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("ParseStream: subgraph %s is not closed",
+			stack[len(stack)-1].id)
+	}
+	return nil
+}
+
+// parseStatement parses a single trimmed line, an empty kind means the line
+// defines no item.
+func parseStatement(line string, stack []*Subgraph, edges int) (
+	kind string, item interface{}, err error) {
+	if m := parseData.FindStringSubmatch(line); m != nil {
+		if n := parseNodeData(m[1], m[2]); n != nil {
+			return ParsedNode, n, nil
+		}
+	}
+	if line == "" || strings.Contains(line, "@{") {
+		return "", nil, nil
+	}
+	for _, prefix := range parseSkipped {
+		if strings.HasPrefix(line, prefix) {
+			return "", nil, nil
+		}
+	}
+	if line == "end" {
+		if len(stack) == 0 {
+			return "", nil, fmt.Errorf("end without subgraph")
+		}
+		return ParsedEnd, stack[len(stack)-1], nil
+	}
+	if strings.HasPrefix(line, "subgraph ") {
+		title := strings.TrimSpace(strings.TrimPrefix(line, "subgraph "))
+		sg := &Subgraph{id: title, Title: title}
+		if m := parseTitled.FindStringSubmatch(title); m != nil {
+			sg.id = m[1]
+			sg.Title = strings.Join(unescapeLines(m[2]), "\n")
+		}
+		if len(stack) > 0 {
+			sg.parent = stack[len(stack)-1]
+		}
+		return ParsedSubgraph, sg, nil
+	}
+	if m := parseEdge.FindStringSubmatch(line); m != nil {
+		e, err := parseEdgeStatement(m)
+		if err != nil {
+			return "", nil, err
+		}
+		e.id = edges
+		return ParsedEdge, e, nil
+	}
+	if m := parseNode.FindStringSubmatch(line); m != nil {
+		n := &Node{id: m[1], Shape: NShapeRect}
+		if m[2] == "" {
+			return ParsedNode, n, nil
+		}
+		for shape := range validNodeShapes {
+			parts := strings.SplitN(string(shape), "%s", 2)
+			text := strings.TrimSuffix(strings.TrimPrefix(m[2], parts[0]), parts[1])
+			if len(text)+len(parts[0])+len(parts[1]) == len(m[2]) &&
+				!strings.Contains(text, `"`) {
+				n.Shape = shape
+				n.Text = unescapeLines(text)
+				return ParsedNode, n, nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("unsupported statement %q", line)
+}
+
+// Edge shapes by their start and end characters as matched by parseEnded
+var parsedEndings = map[string]edgeShape{
+	"o": EShapeCircle, "x": EShapeCross, "<>": EShapeBidirectional,
+	"oo": EShapeCircleBoth, "xx": EShapeCrossBoth,
+}
+
+// Helperfunction to build an Edge from the submatches of parseEdge.
+func parseEdgeStatement(m []string) (*Edge, error) {
+	e := &Edge{From: &Node{id: m[1], Shape: NShapeRect}, name: m[2],
+		To: &Node{id: m[5], Shape: NShapeRect}}
+	if m[4] != "" {
+		e.Text = unescapeLines(m[4])
+	}
+	arrow := m[3]
+	if d := parseDotted.FindStringSubmatch(arrow); d != nil {
+		e.Shape = EShapeDottedLine
+		if d[2] == ">" {
+			e.Shape = EShapeDottedArrow
+		}
+		e.MinLength = len(d[1])
+	} else if s := parseSolid.FindStringSubmatch(arrow); s != nil &&
+		(s[2] == ">" || s[2] == s[1][:1]) {
+		thick := s[1][0] == '='
+		switch {
+		case s[2] == ">" && thick:
+			e.Shape = EShapeThickArrow
+		case s[2] == ">":
+			e.Shape = EShapeArrow
+		case thick:
+			e.Shape = EShapeThickLine
+		default:
+			e.Shape = EShapeLine
+		}
+		e.MinLength = len(s[1]) - 1
+	} else if parseHidden.MatchString(arrow) {
+		e.Shape = EShapeInvisible
+		e.MinLength = len(arrow) - 2
+	} else if s := parseEnded.FindStringSubmatch(arrow); s != nil &&
+		parsedEndings[s[1]+s[3]] != "" {
+		e.Shape = parsedEndings[s[1]+s[3]]
+		e.MinLength = len(s[2]) - 1
+	} else {
+		return nil, fmt.Errorf("unsupported edge shape %q", arrow)
+	}
+	if e.MinLength == 1 {
+		e.MinLength = 0
+	}
+	return e, nil
+}
+
+// parseNodeData parses the attribute syntax of image Nodes and extended shapes,
+// nil is returned for other attributes like those of Edges.
+func parseNodeData(id, data string) *Node {
+	fields := make(map[string]string)
+	for _, m := range parseField.FindAllStringSubmatch(data, -1) {
+		fields[m[1]] = strings.Trim(m[2], `"`)
+	}
+	label, labeled := fields["label"]
+	shape := extendedShape(fields["shape"])
+	if !labeled || (fields["img"] == "" && fields["icon"] == "" &&
+		!validExtendedShapes[shape]) {
+		return nil
+	}
+	n := &Node{id: id, Shape: NShapeRect, Text: unescapeLines(label),
+		ImageURL: fields["img"], LabelPosition: labelPosition(fields["pos"]),
+		Icon: fields["icon"], IconForm: iconForm(fields["form"])}
+	if validExtendedShapes[shape] {
+		n.extShape = shape
+	}
+	n.ImageWidth, _ = strconv.Atoi(fields["w"])
+	n.ImageHeight, _ = strconv.Atoi(fields["h"])
+	return n
+}
+
+// unescapeLines reverts escapeLines, including numeric entity codes of any
+// other characters like those EscapeUnicode produces.
+func unescapeLines(text string) []string {
+	lines := strings.Split(text, "<br/>")
+	for i, line := range lines {
+		lines[i] = parseEntity.ReplaceAllStringFunc(line, func(entity string) string {
+			if entity == "#quot;" {
+				return `"`
+			}
+			code, err := strconv.Atoi(entity[1 : len(entity)-1])
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return entity
+			}
+			return string(rune(code))
+		})
+	}
+	return lines
+}
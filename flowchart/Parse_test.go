@@ -0,0 +1,218 @@
+package flowchart_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/StephenBrown2/mermaidgen/flowchart"
+)
+
+// Processing a mermaid file item by item
+func ExampleParseStream() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("n1")
+	n1.AddLines(`a "quoted"`, "second line")
+	n1.Style = f.NodeStyle("ns1")
+	sg := f.AddSubgraph("sg1")
+	sg.Title = "group"
+	n2 := sg.AddNode("n2")
+	n2.Shape = flowchart.NShapeCylinder
+	f.AddEdgeShaped(n1, n2, flowchart.EShapeDottedArrow, "reads").MinLength = 2
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			switch v := item.(type) {
+			case *flowchart.Node:
+				fmt.Printf("%s %s %q\n", kind, v.ID(), v.Text)
+			case *flowchart.Edge:
+				fmt.Printf("%s %s -> %s %q %d\n", kind, v.From.ID(), v.To.ID(),
+					v.Text, v.MinLength)
+			case *flowchart.Subgraph:
+				fmt.Println(kind, v.ID())
+			}
+			return nil
+		})
+	fmt.Println(err)
+	//Output:
+	//node n1 ["a \"quoted\"" "second line"]
+	//subgraph group
+	//node n2 ["n2"]
+	//end group
+	//edge n1 -> n2 ["reads"] 2
+	//<nil>
+}
+
+func TestParseStream(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	shapes := []flowchart.Node{{Shape: flowchart.NShapeRect},
+		{Shape: flowchart.NShapeRoundRect}, {Shape: flowchart.NShapeCircle},
+		{Shape: flowchart.NShapeRhombus}, {Shape: flowchart.NShapeFlagLeft},
+		{Shape: flowchart.NShapeSubroutine}, {Shape: flowchart.NShapeCylinder},
+		{Shape: flowchart.NShapeDoubleCircle}, {Shape: flowchart.NShapeStadium},
+		{Shape: flowchart.NShapeHexagon}, {Shape: flowchart.NShapeParallelogram},
+		{Shape: flowchart.NShapeParallelogramAlt},
+		{Shape: flowchart.NShapeTrapezoid}, {Shape: flowchart.NShapeTrapezoidAlt}}
+	for i, proto := range shapes {
+		n := f.AddNode(fmt.Sprintf("n%d", i))
+		n.Shape = proto.Shape
+		n.AddLines("[(x)]")
+	}
+	edges := []flowchart.Edge{{Shape: flowchart.EShapeArrow},
+		{Shape: flowchart.EShapeDottedArrow}, {Shape: flowchart.EShapeThickArrow},
+		{Shape: flowchart.EShapeLine}, {Shape: flowchart.EShapeDottedLine},
+		{Shape: flowchart.EShapeThickLine, MinLength: 3}}
+	for _, proto := range edges {
+		e := f.AddEdge(f.GetNode("n0"), f.GetNode("n1"))
+		e.Shape = proto.Shape
+		e.MinLength = proto.MinLength
+	}
+	parsed := flowchart.NewFlowchart()
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			switch v := item.(type) {
+			case *flowchart.Node:
+				n := parsed.AddNode(v.ID())
+				n.Shape = v.Shape
+				n.Text = v.Text
+			case *flowchart.Edge:
+				e := parsed.AddEdge(parsed.GetNode(v.From.ID()),
+					parsed.GetNode(v.To.ID()))
+				e.Shape = v.Shape
+				e.MinLength = v.MinLength
+			}
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, parsed.String() == f.String(), "round trip failed:\n%s", parsed)
+
+	err = flowchart.ParseStream(strings.NewReader("graph TB\n  a & b\n"),
+		func(string, interface{}) error { return nil })
+	assert(t, err != nil && err.Error() ==
+		`ParseStream: line 2: unsupported statement "a & b"`, "unexpected %v", err)
+	err = flowchart.ParseStream(strings.NewReader("graph TB\nsubgraph x\n"),
+		func(string, interface{}) error { return nil })
+	assert(t, err != nil, "unclosed subgraph not detected")
+	stop := fmt.Errorf("stop")
+	err = flowchart.ParseStream(strings.NewReader("graph TB\na\nb\n"),
+		func(string, interface{}) error { return stop })
+	assert(t, err == stop, "error of fn not returned")
+}
+
+func TestParseStream_unicodeEntities(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.EscapeUnicode = true
+	f.AddNode("n").AddLines(`Café #35; "ü"`)
+	var text []string
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			if n, ok := item.(*flowchart.Node); ok {
+				text = n.Text
+			}
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, len(text) == 1 && text[0] == `Café #35; "ü"`,
+		"entities not reverted: %q", text)
+}
+
+func TestParseStream_extendedShape(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	f.AddNode("n").SetExtendedShape(flowchart.XShapeLinedDoc)
+	var parsed *flowchart.Node
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			parsed, _ = item.(*flowchart.Node)
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, parsed != nil && parsed.ID() == "n" &&
+		parsed.ExtendedShape() == flowchart.XShapeLinedDoc,
+		"extended shape not parsed: %v", parsed)
+}
+
+func TestParseStream_image(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	n := f.AddNode("n")
+	n.AddLines("Logo")
+	n.ImageURL = "https://example.com/logo.png"
+	n.ImageWidth = 40
+	n.LabelPosition = flowchart.LabelBottom
+	var parsed *flowchart.Node
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			parsed, _ = item.(*flowchart.Node)
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, parsed != nil && parsed.ImageURL == n.ImageURL &&
+		parsed.ImageWidth == 40 && parsed.ImageHeight == 0 &&
+		parsed.LabelPosition == flowchart.LabelBottom &&
+		len(parsed.Text) == 1 && parsed.Text[0] == "Logo",
+		"image Node not parsed: %v", parsed)
+}
+
+func TestParseStream_icon(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.MermaidVersion = flowchart.MermaidV11
+	n := f.AddNode("n")
+	n.Icon = "aws:ec2"
+	n.IconForm = flowchart.IconFormCircle
+	var parsed *flowchart.Node
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			parsed, _ = item.(*flowchart.Node)
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, parsed != nil && parsed.Icon == "aws:ec2" &&
+		parsed.IconForm == flowchart.IconFormCircle,
+		"icon Node not parsed: %v", parsed)
+}
+
+func TestParseStream_escapedTitle(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.AddSubgraph("sg1").Title = "a [b] #1\nc"
+	var parsed *flowchart.Subgraph
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			parsed, _ = item.(*flowchart.Subgraph)
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, parsed != nil && parsed.ID() == "sg1" &&
+		parsed.Title == "a [b] #1\nc", "title not parsed: %v", parsed)
+}
+
+func TestParseStream_edgeEndings(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	a, b := f.AddNode("a"), f.AddNode("b")
+	edges := []*flowchart.Edge{
+		f.AddEdgeShaped(a, b, flowchart.EShapeCircle, ""),
+		f.AddEdgeShaped(a, b, flowchart.EShapeCross, ""),
+		f.AddEdgeShaped(a, b, flowchart.EShapeBidirectional, ""),
+		f.AddEdgeShaped(a, b, flowchart.EShapeCircleBoth, ""),
+		f.AddEdgeShaped(a, b, flowchart.EShapeCrossBoth, ""),
+		f.AddInvisibleEdge(a, b),
+		f.AddInvisibleEdge(a, b),
+	}
+	edges[6].MinLength = 2
+	edges[1].MinLength = 3
+	edges[2].MinLength = 2
+	parsed := []*flowchart.Edge{}
+	err := flowchart.ParseStream(strings.NewReader(f.String()),
+		func(kind string, item interface{}) error {
+			if e, ok := item.(*flowchart.Edge); ok {
+				parsed = append(parsed, e)
+			}
+			return nil
+		})
+	assert(t, err == nil, "unexpected error %v", err)
+	assert(t, len(parsed) == len(edges), "expected %d Edges, got %d",
+		len(edges), len(parsed))
+	for i, e := range parsed {
+		assert(t, e.Shape == edges[i].Shape && e.MinLength == edges[i].MinLength,
+			"Edge %d parsed as %s with length %d", i, e.Shape, e.MinLength)
+	}
+}
@@ -0,0 +1,358 @@
+package flowchart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// average glyph widths in em of a proportional sans-serif font like mermaid's
+// default, by character class
+const (
+	glyphNarrow = 0.28 // e.g. i, l, punctuation and spaces
+	glyphLower  = 0.52 // most lowercase letters
+	glyphDigit  = 0.56 // digits
+	glyphUpper  = 0.67 // most uppercase letters and symbols
+	glyphWide   = 0.86 // m, w, M, W
+	glyphFull   = 1.0  // CJK and other fullwidth characters
+	glyphMono   = 0.6  // any glyph of a monospace font
+)
+
+// EstimateTextWidth approximates the width in px that s takes when rendered in
+// a proportional sans-serif font of the given size, like mermaid's default
+// font. Lines separated by <br/> or newlines are measured separately and the
+// widest one is returned. The estimate uses average glyph widths of character
+// classes instead of actual font metrics, so it is only an approximation,
+// good enough to lay out boxes or warn about oversized labels. See
+// EstimateTextWidthMonospace for monospace fonts.
+func EstimateTextWidth(s string, fontSizePx float64) (px float64) {
+	return estimateWidth(s, fontSizePx, glyphWidth)
+}
+
+// EstimateTextWidthMonospace returns the width in px that s takes when rendered
+// in a monospace font of the given size, measuring lines like
+// EstimateTextWidth does. Since all glyphs of monospace fonts have the same
+// advance of about 0.6 em (1.2 em for fullwidth characters like CJK), the
+// width is exact for common monospace fonts like Courier or DejaVu Sans Mono.
+func EstimateTextWidthMonospace(s string, fontSizePx float64) (px float64) {
+	return estimateWidth(s, fontSizePx, func(r rune) float64 {
+		switch glyphWidth(r) {
+		case 0:
+			return 0
+		case glyphFull:
+			return 2 * glyphMono
+		}
+		return glyphMono
+	})
+}
+
+// estimateWidth implements EstimateTextWidth with the given glyph widths.
+func estimateWidth(s string, fontSizePx float64,
+	width func(r rune) float64) float64 {
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	widest := 0.0
+	for _, line := range strings.Split(s, "<br/>") {
+		em := 0.0
+		for _, r := range line {
+			em += width(r)
+		}
+		widest = math.Max(widest, em)
+	}
+	return widest * fontSizePx
+}
+
+// glyphWidth returns the average width in em of the class of r.
+func glyphWidth(r rune) float64 {
+	switch {
+	case strings.ContainsRune("mwMW", r):
+		return glyphWide
+	case strings.ContainsRune("fijlrtI!|.,:;'`()[] ", r):
+		return glyphNarrow
+	case r >= '0' && r <= '9':
+		return glyphDigit
+	case r >= 'a' && r <= 'z':
+		return glyphLower
+	case r >= 0x1100 && (unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hangul, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || (r >= 0xFF00 && r <= 0xFF60)):
+		return glyphFull
+	case unicode.IsMark(r) || unicode.IsControl(r):
+		return 0
+	}
+	return glyphUpper
+}
+
+// SVGOptions configures RenderBasicSVG. Zero values select the defaults given
+// for each field.
+type SVGOptions struct {
+	NodeWidth   int // Minimum width of Nodes in px (default 120).
+	NodeHeight  int // Height of Nodes in px (default 40).
+	NodeSpacing int // Space between Nodes of the same rank in px (default 40).
+	RankSpacing int // Space between ranks in px (default 60).
+	FontSize    int // Font size in px (default 14).
+}
+
+// helper to get a value or its default if the value is not positive
+func orDefault(value, def int) float64 {
+	if value > 0 {
+		return float64(value)
+	}
+	return float64(def)
+}
+
+// horizontal padding of Node labels in px
+const svgPadding = 10.0
+
+// shapes supported by RenderBasicSVG
+var basicSVGShapes = map[nodeShape]bool{
+	NShapeRect:      true,
+	NShapeRoundRect: true,
+	NShapeRhombus:   true,
+	NShapeStadium:   true,
+}
+
+// RenderBasicSVG renders the Flowchart to a simple SVG without the mermaid CLI,
+// e.g. for CI artifacts. It doesn't try to match mermaid's layout: Nodes get
+// the same size, widened to fit the widest label (see EstimateTextWidth), and
+// are placed in layers by rank (the longest path from a Node without incoming
+// Edges, Nodes on cycles are ranked by their ranked predecessors), ordered by
+// ID within each rank and the layers follow the Direction. Edges are straight
+// lines with their labels rendered at their middle, dotted and thick shapes
+// and arrow heads are honored, other endings (e.g. EShapeCircle) and
+// EShapeBidirectional are drawn as plain lines and EShapeInvisible Edges only
+// affect the ranks. The supported subset are the Node shapes NShapeRect,
+// NShapeRoundRect, NShapeRhombus and NShapeStadium, Node and Edge texts and
+// the Direction. Subgraphs, Styles, size hints and clicks are ignored. An
+// error is returned for Nodes of other shapes and Edges to Nodes of other
+// Flowcharts.
+func (fc *Flowchart) RenderBasicSVG(opts SVGOptions) (svg []byte, err error) {
+	w, h := orDefault(opts.NodeWidth, 120), orDefault(opts.NodeHeight, 40)
+	nodeSpacing := orDefault(opts.NodeSpacing, 40)
+	rankSpacing := orDefault(opts.RankSpacing, 60)
+	fontSize := orDefault(opts.FontSize, 14)
+
+	nodes := fc.ListNodes()
+	for _, n := range nodes {
+		if !basicSVGShapes[n.EffectiveShape()] {
+			return nil, fmt.Errorf("RenderBasicSVG: unsupported shape %q of Node %s",
+				string(n.EffectiveShape()), n.id)
+		}
+	}
+	for _, e := range fc.edges {
+		if e.From == nil || e.To == nil || fc.nodes[e.From.id] != e.From ||
+			fc.nodes[e.To.id] != e.To {
+			return nil, fmt.Errorf(
+				"RenderBasicSVG: Edge %d connects Nodes of another Flowchart", e.id)
+		}
+	}
+
+	// all Nodes get the size of the widest label
+	for _, n := range nodes {
+		w = math.Max(w, EstimateTextWidth(nodeLabel(n, "\n"), fontSize)+2*svgPadding)
+	}
+
+	// layered layout
+	ranks := fc.ranks(nodes)
+	layers := [][]*Node{}
+	for _, n := range nodes {
+		for len(layers) <= ranks[n] {
+			layers = append(layers, nil)
+		}
+		layers[ranks[n]] = append(layers[ranks[n]], n)
+	}
+	widest := 0
+	for _, layer := range layers {
+		if len(layer) > widest {
+			widest = len(layer)
+		}
+	}
+	horizontal := fc.Direction == DirectionLeftRight ||
+		fc.Direction == DirectionRightLeft
+	// along the ranks and across them
+	along, across := h, w
+	if horizontal {
+		along, across = w, h
+	}
+	const margin = 20.0
+	length := float64(len(layers))*along + float64(len(layers)-1)*rankSpacing
+	breadth := float64(widest)*across + float64(widest-1)*nodeSpacing
+	if len(layers) == 0 {
+		length, breadth = 0, 0
+	}
+	centers := make(map[*Node][2]float64)
+	for r, layer := range layers {
+		a := margin + float64(r)*(along+rankSpacing) + along/2
+		if fc.Direction == DirectionBottomUp || fc.Direction == DirectionRightLeft {
+			a = margin + length - (a - margin)
+		}
+		offset := (breadth - float64(len(layer))*across -
+			float64(len(layer)-1)*nodeSpacing) / 2
+		for i, n := range layer {
+			b := margin + offset + float64(i)*(across+nodeSpacing) + across/2
+			if horizontal {
+				centers[n] = [2]float64{a, b}
+			} else {
+				centers[n] = [2]float64{b, a}
+			}
+		}
+	}
+	width, height := breadth+2*margin, length+2*margin
+	if horizontal {
+		width, height = height, width
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s"`+
+		` viewBox="0 0 %s %s" font-family="sans-serif" font-size="%s">`+"\n",
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height),
+		svgNum(fontSize))
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10"` +
+		` refY="5" markerWidth="8" markerHeight="8" orient="auto">` +
+		`<path d="M 0 0 L 10 5 L 0 10 z" fill="#333"/></marker></defs>` + "\n")
+	for _, e := range fc.edges {
+		if e.Shape == EShapeInvisible {
+			continue
+		}
+		start, end := e.renderedEnds()
+		from, to := centers[start], centers[end]
+		x1, y1 := svgClip(from, to, w/2, h/2)
+		x2, y2 := svgClip(to, from, w/2, h/2)
+		attributes := ""
+		switch e.Shape {
+		case EShapeDottedArrow, EShapeDottedLine:
+			attributes += ` stroke-dasharray="3,3"`
+		case EShapeThickArrow, EShapeThickLine:
+			attributes += ` stroke-width="3"`
+		}
+		switch e.Shape {
+		case EShapeArrow, EShapeDottedArrow, EShapeThickArrow:
+			attributes += ` marker-end="url(#arrow)"`
+		}
+		fmt.Fprintf(&b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#333"%s/>`+
+			"\n", svgNum(x1), svgNum(y1), svgNum(x2), svgNum(y2), attributes)
+		if len(e.Text) > 0 {
+			svgText(&b, (x1+x2)/2, (y1+y2)/2, fontSize, e.Text)
+		}
+	}
+	for _, n := range nodes {
+		c := centers[n]
+		switch n.EffectiveShape() {
+		case NShapeRhombus:
+			fmt.Fprintf(&b, `<polygon points="%s,%s %s,%s %s,%s %s,%s"`+
+				` fill="#fff" stroke="#333"/>`+"\n",
+				svgNum(c[0]), svgNum(c[1]-h/2), svgNum(c[0]+w/2), svgNum(c[1]),
+				svgNum(c[0]), svgNum(c[1]+h/2), svgNum(c[0]-w/2), svgNum(c[1]))
+		default:
+			rx := 0.0
+			switch n.EffectiveShape() {
+			case NShapeRoundRect:
+				rx = h / 4
+			case NShapeStadium:
+				rx = h / 2
+			}
+			fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s"`+
+				` fill="#fff" stroke="#333"/>`+"\n", svgNum(c[0]-w/2),
+				svgNum(c[1]-h/2), svgNum(w), svgNum(h), svgNum(rx))
+		}
+		text := n.Text
+		if len(text) == 0 {
+			text = []string{n.id}
+		}
+		svgText(&b, c[0], c[1], fontSize, text)
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes(), nil
+}
+
+// ranks assigns each Node the length of the longest path from a Node without
+// incoming Edges. Nodes on cycles are ranked after their ranked predecessors,
+// visiting them in the given order.
+func (fc *Flowchart) ranks(nodes []*Node) map[*Node]int {
+	incoming := make(map[*Node]int)
+	outgoing := make(map[*Node][]*Node)
+	for _, e := range fc.edges {
+		if from, to := e.renderedEnds(); from != to {
+			incoming[to]++
+			outgoing[from] = append(outgoing[from], to)
+		}
+	}
+	ranks := make(map[*Node]int)
+	done := make(map[*Node]bool)
+	queue := []*Node{}
+	for _, n := range nodes {
+		if incoming[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(done) < len(nodes) {
+		if len(queue) == 0 {
+			// break a cycle at the first Node not ranked yet
+			for _, n := range nodes {
+				if !done[n] {
+					queue = append(queue, n)
+					break
+				}
+			}
+		}
+		n := queue[0]
+		queue = queue[1:]
+		if done[n] {
+			continue
+		}
+		done[n] = true
+		for _, x := range outgoing[n] {
+			if done[x] {
+				continue
+			}
+			if ranks[n]+1 > ranks[x] {
+				ranks[x] = ranks[n] + 1
+			}
+			incoming[x]--
+			if incoming[x] == 0 {
+				queue = append(queue, x)
+			}
+		}
+	}
+	return ranks
+}
+
+// svgClip returns the point where the line from center c to the point p leaves
+// the box of the given half width and half height around c.
+func svgClip(c, p [2]float64, hw, hh float64) (x, y float64) {
+	dx, dy := p[0]-c[0], p[1]-c[1]
+	if dx == 0 && dy == 0 {
+		return c[0], c[1]
+	}
+	t := math.Inf(1)
+	if dx != 0 {
+		t = math.Min(t, hw/math.Abs(dx))
+	}
+	if dy != 0 {
+		t = math.Min(t, hh/math.Abs(dy))
+	}
+	return c[0] + dx*t, c[1] + dy*t
+}
+
+// svgText renders the lines of text centered at x, y.
+func svgText(b *bytes.Buffer, x, y, fontSize float64, lines []string) {
+	top := y - float64(len(lines)-1)*fontSize*1.2/2
+	fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle"`+
+		` dominant-baseline="middle">`, svgNum(x), svgNum(top))
+	for i, line := range lines {
+		dy := "0"
+		if i > 0 {
+			dy = svgNum(fontSize * 1.2)
+		}
+		fmt.Fprintf(b, `<tspan x="%s" dy="%s">%s</tspan>`, svgNum(x), dy,
+			html.EscapeString(line))
+	}
+	b.WriteString("</text>\n")
+}
+
+// svgNum formats a coordinate with at most one decimal.
+func svgNum(f float64) string {
+	return fmt.Sprint(math.Round(f*10) / 10)
+}
@@ -0,0 +1,101 @@
+package flowchart_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/StephenBrown2/mermaidgen/flowchart"
+)
+
+// Rendering a small Flowchart to SVG without the mermaid CLI
+func ExampleFlowchart_RenderBasicSVG() {
+	f := flowchart.NewFlowchart()
+	n1 := f.AddNode("start")
+	n2 := f.AddNode("check")
+	n2.Shape = flowchart.NShapeRhombus
+	e := f.AddEdge(n1, n2)
+	e.AddLines("go")
+	svg, err := f.RenderBasicSVG(flowchart.SVGOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(svg))
+	// Output:
+	// <svg xmlns="http://www.w3.org/2000/svg" width="160" height="180" viewBox="0 0 160 180" font-family="sans-serif" font-size="14">
+	// <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M 0 0 L 10 5 L 0 10 z" fill="#333"/></marker></defs>
+	// <line x1="80" y1="60" x2="80" y2="120" stroke="#333" marker-end="url(#arrow)"/>
+	// <text x="80" y="90" text-anchor="middle" dominant-baseline="middle"><tspan x="80" dy="0">go</tspan></text>
+	// <polygon points="80,120 140,140 80,160 20,140" fill="#fff" stroke="#333"/>
+	// <text x="80" y="140" text-anchor="middle" dominant-baseline="middle"><tspan x="80" dy="0">check</tspan></text>
+	// <rect x="20" y="20" width="120" height="40" rx="0" fill="#fff" stroke="#333"/>
+	// <text x="80" y="40" text-anchor="middle" dominant-baseline="middle"><tspan x="80" dy="0">start</tspan></text>
+	// </svg>
+}
+
+func ExampleEstimateTextWidth() {
+	fmt.Println(flowchart.EstimateTextWidth("Hello", 10))
+	fmt.Println(flowchart.EstimateTextWidth("short<br/>a longer line", 10))
+	//Output:
+	//22.7
+	//53.2
+}
+
+func TestEstimateTextWidthMonospace(t *testing.T) {
+	for s, want := range map[string]float64{
+		"": 0, "Hello": 30, "iiii<br/>MMMMMM": 36, "日本": 24, "e\u0301": 6,
+	} {
+		got := flowchart.EstimateTextWidthMonospace(s, 10)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("EstimateTextWidthMonospace(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestFlowchart_RenderBasicSVG(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	f.Direction = flowchart.DirectionLeftRight
+	a, b, c := f.AddNode("a"), f.AddNode("b"), f.AddNode("c")
+	a.AddLines("x < y", "& more")
+	b.Shape = flowchart.NShapeRoundRect
+	c.Shape = flowchart.NShapeStadium
+	f.AddEdge(a, b)
+	f.AddEdge(b, c)
+	f.AddEdge(c, a) // cycle
+	svg, err := f.RenderBasicSVG(flowchart.SVGOptions{NodeWidth: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := xml.NewDecoder(strings.NewReader(string(svg)))
+	elements := map[string]int{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %s\n%s", err, svg)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			elements[start.Name.Local]++
+		}
+	}
+	if elements["rect"] != 3 || elements["line"] != 3 || elements["tspan"] != 4 {
+		t.Errorf("unexpected elements %v in\n%s", elements, svg)
+	}
+	if !strings.Contains(string(svg), `rx="20"`) {
+		t.Errorf("expected a stadium with fully rounded ends in\n%s", svg)
+	}
+	if !strings.Contains(string(svg), `width="400"`) {
+		t.Errorf("expected three ranks side by side in\n%s", svg)
+	}
+
+	f.AddNode("d").Shape = flowchart.NShapeCircle
+	if _, err := f.RenderBasicSVG(flowchart.SVGOptions{}); err == nil {
+		t.Error("expected an error for an unsupported shape")
+	}
+}
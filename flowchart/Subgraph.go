@@ -2,6 +2,8 @@ package flowchart
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // Subgraph represents a subgraph block on the Flowchart graph where nested
@@ -12,7 +14,9 @@ import (
 type Subgraph struct {
 	id        string      // virtual ID for lookup
 	flowchart *Flowchart  // top lvl pointer
+	parent    *Subgraph   // containing Subgraph, nil for top lvl
 	items     []graphItem // sub-items to render
+	legend    bool        // render samples of all NodeStyles, too
 	Title     string      // The title of this Subgraph.
 }
 
@@ -27,21 +31,72 @@ func (sg *Subgraph) Flowchart() (topLevel *Flowchart) {
 	return sg.flowchart
 }
 
+// Parent provides access to the Subgraph containing this Subgraph. If this
+// Subgraph was added to the Flowchart itself, nil is returned.
+func (sg *Subgraph) Parent() (containingSubgraph *Subgraph) {
+	return sg.parent
+}
+
+// Path returns the IDs of all Subgraphs from the top level down to this
+// Subgraph, e.g. for breadcrumb navigation. The last element is this
+// Subgraph's own ID.
+func (sg *Subgraph) Path() (ids []string) {
+	for s := sg; s != nil; s = s.parent {
+		ids = append([]string{s.id}, ids...)
+	}
+	return ids
+}
+
 // Implements graphItem, see String() for further details.
-func (sg *Subgraph) renderGraph() string {
-	text := fmt.Sprintln("  subgraph", sg.Title)
-	for _, item := range sg.items {
-		text += "  " + item.renderGraph()
+func (sg *Subgraph) renderGraph(rc *renderContext) string {
+	text := rc.record(fmt.Sprintln("  subgraph", sg.renderTitle()), sg)
+	for _, item := range rc.order(sg.items) {
+		text += "  " + item.renderGraph(rc)
+	}
+	if sg.legend {
+		fc := sg.flowchart
+		used := make(map[string]bool)
+		taken := func(id string) bool {
+			return used[id] || fc.nodes[id] != nil || fc.subgraphs[id] != nil
+		}
+		for _, style := range fc.listNodeStyles() {
+			// samples must not take over the ID of a real Node or Subgraph
+			id := sg.id + "_" + style.id
+			for i := 2; taken(id); i++ {
+				id = fmt.Sprintf("%s_%s_%d", sg.id, style.id, i)
+			}
+			used[id] = true
+			sample := &Node{id: id, flowchart: fc, Shape: NShapeRect,
+				Text: []string{style.id}, Style: style}
+			text += "  " + sample.renderGraph(rc)
+		}
 	}
 
-	text += "  end\n"
+	text += rc.record("  end\n", sg)
 
 	return text
 }
 
-// String renders this graph element to a subgraph block.
+// renderTitle renders the Title as is if mermaid reads it back unchanged,
+// otherwise escaped and quoted behind the escaped ID.
+func (sg *Subgraph) renderTitle() string {
+	unicode := sg.flowchart != nil && sg.flowchart.EscapeUnicode
+	plain := !strings.ContainsAny(sg.Title, "#\"[](){}|\r\n")
+	for _, r := range sg.Title {
+		plain = plain && (!unicode || r < utf8.RuneSelf)
+	}
+	if plain {
+		return sg.Title
+	}
+	return EscapeID(sg.id) + `["` + escapeLines([]string{sg.Title}, unicode) +
+		`"]`
+}
+
+// String renders this graph element to a subgraph block. The Title is
+// rendered as is, or if it contains characters mermaid would misread (see
+// EscapeLabel) escaped and quoted after the ID as <ID>["title"].
 func (sg *Subgraph) String() (renderedElement string) {
-	return sg.renderGraph()
+	return sg.renderGraph(&renderContext{})
 }
 
 // AddSubgraph is used to add another nested Subgraph below this Subgraph layer.
@@ -53,7 +108,7 @@ func (sg *Subgraph) AddSubgraph(id string) (newSubgraph *Subgraph) {
 	if alreadyExists {
 		return nil
 	}
-	s := &Subgraph{id: id, flowchart: sg.flowchart}
+	s := &Subgraph{id: id, flowchart: sg.flowchart, parent: sg}
 	sg.flowchart.subgraphs[id] = s
 	sg.items = append(sg.items, s)
 	return s
@@ -67,8 +122,40 @@ func (sg *Subgraph) AddNode(id string) (newNode *Node) {
 	if alreadyExists {
 		return nil
 	}
-	n := &Node{id: id, Shape: NShapeRect}
+	n := &Node{id: id, flowchart: sg.flowchart, Shape: NShapeRect}
 	sg.flowchart.nodes[id] = n
 	sg.items = append(sg.items, n)
 	return n
 }
+
+// AsFlowchart returns a standalone deep copy of this Subgraph's content, e.g.
+// to render a detail diagram per Subgraph. The Nodes and nested Subgraphs
+// become the top level items of the new Flowchart, the Edges between them are
+// copied, Edges leaving the Subgraph are dropped. Settings and styles are
+// copied like Clone does.
+func (sg *Subgraph) AsFlowchart() (fc *Flowchart) {
+	inside := make(map[*Node]bool)
+	walkNodes(sg.items, sg, func(n *Node, _ *Subgraph) { inside[n] = true })
+	fc = sg.flowchart.copyWhere(func(n *Node) bool { return inside[n] })
+	copied := fc.subgraphs[sg.id]
+	if copied == nil {
+		return fc // no Nodes to copy
+	}
+	for _, id := range sg.Path() {
+		delete(fc.subgraphs, id)
+	}
+	fc.items = copied.items
+	for _, item := range fc.items {
+		if child, ok := item.(*Subgraph); ok {
+			child.parent = nil
+		}
+	}
+	return fc
+}
+
+// clone returns a copy of this Subgraph without any items, belonging to the
+// given Flowchart.
+func (sg *Subgraph) clone(fc *Flowchart) *Subgraph {
+	return &Subgraph{id: sg.id, flowchart: fc, legend: sg.legend,
+		Title: sg.Title}
+}
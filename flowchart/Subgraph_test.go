@@ -2,8 +2,9 @@ package flowchart_test
 
 import (
 	"fmt"
+	"testing"
 
-	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 // Working with Subgraphs
@@ -25,17 +26,19 @@ func ExampleSubgraph() {
 	fmt.Print(f)
 	//Output:
 	//graph TB
-	//subgraph vpc-123
-	//subgraph AZ a
-	//i-123["i-123"]
-	//mydb["mydb"]
-	//end
-	//subgraph AZ b
-	//i-456["i-456"]
-	//end
-	//end
-	//i-123 --> mydb
-	//i-456 --> mydb
+	//
+	//   subgraph vpc-123
+	//     subgraph AZ a
+	//     i-123["i-123"]
+	//     mydb["mydb"]
+	//   end
+	//     subgraph AZ b
+	//     i-456["i-456"]
+	//   end
+	//   end
+	//
+	//   i-123 --> mydb
+	//   i-456 --> mydb
 }
 
 // Accessing the readonly fields of a Subgraph
@@ -51,6 +54,23 @@ func ExampleSubgraph_privateFields() {
 	//Output: this_is_my_id true
 }
 
+// Navigating nested Subgraphs
+func ExampleSubgraph_Path() {
+	f := flowchart.NewFlowchart()
+	vpc := f.AddSubgraph("vpc")
+	az := vpc.AddSubgraph("az-a")
+	subnet := az.AddSubgraph("subnet-1")
+	fmt.Println(vpc.Parent() == nil, subnet.Parent() == az)
+	fmt.Println(subnet.Path())
+	// the parent chain survives cloning
+	c := f.Clone()
+	fmt.Println(c.GetSubgraph("subnet-1").Parent() == c.GetSubgraph("az-a"))
+	//Output:
+	//true true
+	//[vpc az-a subnet-1]
+	//true
+}
+
 // Adding the same ID multiple times won't work
 func ExampleSubgraph_addDuplicate() {
 	f := flowchart.NewFlowchart()
@@ -63,3 +83,46 @@ func ExampleSubgraph_addDuplicate() {
 	fmt.Println(n, s)
 	//Output: <nil> <nil>
 }
+
+// Escaping titles with special characters
+func ExampleSubgraph_escapedTitle() {
+	f := flowchart.NewFlowchart()
+	sg := f.AddSubgraph("db.cluster")
+	sg.Title = `Postgres (primary) "eu|1"`
+	sg.AddNode("n1")
+	plain := f.AddSubgraph("plain")
+	plain.Title = "Read replicas"
+	fmt.Print(f)
+	//Output:
+	//graph TB
+	//
+	//   subgraph db_2e_cluster["Postgres #40;primary#41; #quot;eu#124;1#quot;"]
+	//     n1["n1"]
+	//   end
+	//   subgraph Read replicas
+	//   end
+}
+
+func TestSubgraph_AsFlowchart(t *testing.T) {
+	f := flowchart.NewFlowchart()
+	outer := f.AddSubgraph("outer")
+	inner := outer.AddSubgraph("inner")
+	a, b := inner.AddNode("a"), inner.AddNode("b")
+	c := f.AddNode("c")
+	f.AddEdge(a, b)
+	f.AddEdge(b, c)
+	sub := inner.AsFlowchart()
+	if sub.GetSubgraph("inner") != nil || sub.GetSubgraph("outer") != nil {
+		t.Error("expected the Subgraph itself to be unwrapped")
+	}
+	if len(sub.ListNodes()) != 2 || len(sub.ListEdges()) != 1 {
+		t.Errorf("expected a and b with a single Edge, got\n%s", sub)
+	}
+	whole := outer.AsFlowchart()
+	if sg := whole.GetSubgraph("inner"); sg == nil || sg.Parent() != nil {
+		t.Error("expected inner as top level Subgraph")
+	}
+	if got := f.AddSubgraph("empty").AsFlowchart().ListNodes(); len(got) != 0 {
+		t.Errorf("expected no Nodes, got %v", got)
+	}
+}
@@ -6,9 +6,16 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"runtime"
 	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StephenBrown2/mermaidgen/flowchart"
 )
 
 ////////// AxisFormat //////////////////////////////////////////////////////////
@@ -18,6 +25,8 @@ type axisFormat string
 // Format definitions for x axis scale as described at
 // https://mermaidjs.github.io/gantt.html#scale.
 // The default is no axisFormat statement which results in FormatDate.
+// FormatWeekNumber renders ISO 8601 week numbers, which needs d3-time-format
+// 2.1 or later, i.e. mermaid 8.4 or later.
 const (
 	FormatDateTime24WithSeconds    axisFormat = `%Y-%m-%d %H:%M:%S`
 	FormatDateTime24               axisFormat = `%Y-%m-%d %H:%M`
@@ -28,20 +37,147 @@ const (
 	FormatWeekdayTime24WithSeconds axisFormat = `%a %H:%M:%S`
 	FormatTime24                   axisFormat = `%H:%M`
 	FormatTime24WithSeconds        axisFormat = `%H:%M:%S`
+	FormatWeekNumber               axisFormat = `%V`
 )
 
+// directives of d3-time-format that can be used in an axisFormat
+const axisFormatDirectives = "aAbBcdefgGHIjLmMpqQsSuUVwWxXyYZ%"
+
+// IsValidAxisFormat is used to check if an axisFormat only uses directives
+// known to d3-time-format (e.g. %Y or %V), optionally with a padding modifier
+// (e.g. %-d). Other characters are rendered as they are and always valid.
+func IsValidAxisFormat(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && strings.IndexByte("-_0", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) ||
+			strings.IndexByte(axisFormatDirectives, format[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+////////// DateFormat //////////////////////////////////////////////////////////
+
+type dateFormat string
+
+// Format definitions for input dates as described at
+// https://mermaidjs.github.io/gantt.html#date-format. The DateFormat is used
+// to parse date strings given to Task's SetStart and to render dates, it is
+// independent of the AxisFormat used for display. The default if no
+// DateFormat is given is DateFormatRFC3339.
+const (
+	DateFormatRFC3339      dateFormat = `YYYY-MM-DDTHH:mm:ssZ`
+	DateFormatDateTime     dateFormat = `YYYY-MM-DD HH:mm`
+	DateFormatDate         dateFormat = `YYYY-MM-DD`
+	DateFormatDayMonthYear dateFormat = `DD/MM/YYYY`
+	DateFormatMonthDayYear dateFormat = `MM/DD/YYYY`
+)
+
+// translation of mermaid's (dayjs) date format tokens to Go layout elements,
+// longer tokens must precede their prefixes
+var dateFormatTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"DD", "02"}, {"D", "2"}, {"dddd", "Monday"}, {"ddd", "Mon"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"}, {"ss", "05"}, {"s", "5"}, {"SSS", "000"},
+	{"A", "PM"}, {"a", "pm"}, {"ZZ", "-0700"}, {"Z", "Z07:00"},
+}
+
+// layout translates the dateFormat to a Go time layout.
+func (df dateFormat) layout() string {
+	format := string(df)
+	layout := ""
+	for len(format) > 0 {
+		matched := false
+		for _, t := range dateFormatTokens {
+			if strings.HasPrefix(format, t.token) {
+				layout += t.layout
+				format = format[len(t.token):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			layout += format[:1]
+			format = format[1:]
+		}
+	}
+	return layout
+}
+
+////////// LineEnding ////////////////////////////////////////////////////////
+
+type lineEnding string
+
+// Line ending definitions used when writing a Gantt via WriteTo or WriteFile.
+// The default if no LineEnding is given is LineEndingLF.
+const (
+	LineEndingLF   lineEnding = "\n"
+	LineEndingCRLF lineEnding = "\r\n"
+)
+
+// UTF-8 byte order mark, see Gantt's BOM field.
+const utf8BOM = "\ufeff"
+
+////////// CompatMode ////////////////////////////////////////////////////////
+
+type compatMode int
+
+// Mermaid versions a Gantt can constrain its syntax to via its CompatMode
+// member, so the same diagram renders in older mermaid installations, too.
+// Newer features are downgraded or dropped then as reported by Gantt's
+// Warnings method. The default if no CompatMode is given is CompatLatest.
+const (
+	CompatLatest compatMode = 0
+	CompatV8     compatMode = 8
+	CompatV10    compatMode = 10
+)
+
+// supports reports whether the CompatMode allows features introduced with the
+// given major mermaid version.
+func (g *Gantt) supports(version int) bool {
+	return g.CompatMode == CompatLatest || int(g.CompatMode) >= version
+}
+
 ////////// Gantt ///////////////////////////////////////////////////////////////
 
 // Gantt objects are the entrypoints to this package, the whole diagram is
 // constructed around a Gantt object. Create an instance of Gantt via
 // Gantt's constructor NewGantt, do not create instances directly.
 type Gantt struct {
-	sectionsMap map[string]*Section // lookup table for existing Sections
-	sections    []*Section          // Section items for ordered rendering
-	tasksMap    map[string]*Task    // lookup table for existing Tasks
-	tasks       []*Task             // Section-less Task items
-	Title       string              // Title of the Gantt diagram
-	AxisFormat  axisFormat          // Optional time format for x axis
+	sectionsMap             map[string]*Section // lookup table for existing Sections
+	sections                []*Section          // Section items for ordered rendering
+	tasksMap                map[string]*Task    // lookup table for existing Tasks
+	tasks                   []*Task             // Section-less Task items
+	markers                 []*Task             // milestones of the markers section
+	classDefs               map[string]string   // CSS per custom Task class
+	clicks                  []click             // click directives by Task ID
+	Title                   string              // Title of the Gantt diagram
+	AxisFormat              axisFormat          // Optional time format for x axis
+	DateFormat              dateFormat          // Optional input format for dates
+	ExcludeWeekends         bool                // Exclude the WeekendDays
+	WeekendDays             []time.Weekday      // Days of the weekend (saturday, sunday)
+	ExcludeWeekdays         []time.Weekday      // Exclude the given days of the week
+	ExcludeDates            []time.Time         // Exclude the given dates (time is ignored)
+	LineEnding              lineEnding          // Line ending for WriteTo/WriteFile
+	BOM                     bool                // Prepend a UTF-8 BOM in WriteTo/WriteFile
+	AlwaysRenderIDs         bool                // Render IDs of unreferenced Tasks, too
+	Compact                 bool                // Pack non-overlapping Tasks into rows
+	BarHeight               int                 // Optional height of Task bars in px
+	FontSize                int                 // Optional font size of Tasks in px
+	SectionFontSize         int                 // Optional font size of Sections in px
+	EmptyPlaceholder        string              // Optional title hint if there are no Tasks
+	AnnotateSectionProgress bool                // Append each Section's Progress to its title
+	CompatMode              compatMode          // Optional oldest mermaid version to support
+	KeepEmptySections       bool                // Show Sections without Tasks as dividers
 }
 
 // NewGantt is the constructor used to create a new Gantt object.
@@ -62,6 +198,9 @@ func NewGantt(init ...interface{}) (newGantt *Gantt, err error) {
 		default:
 			return nil, fmt.Errorf("value for AxisFormat was no axisFormat")
 		}
+		if !IsValidAxisFormat(string(g.AxisFormat)) {
+			return nil, fmt.Errorf("value for AxisFormat is no valid axisFormat")
+		}
 		fallthrough
 	case l > 0:
 		g.Title, ok = init[0].(string)
@@ -73,23 +212,320 @@ func NewGantt(init ...interface{}) (newGantt *Gantt, err error) {
 }
 
 // String recursively renders the whole diagram to mermaid code lines.
+// Mermaid doesn't show Sections without Tasks. If KeepEmptySections is set,
+// each of them gets an invisible Task taking no time, so its title still
+// shows as a labeled divider, e.g. for a future phase that isn't planned yet.
+// The placeholder isn't a Task of the Gantt, so Span, Timeline and Progress
+// are not affected, and empty Sections aren't annotated with their Progress.
 func (g *Gantt) String() (renderedElement string) {
-	renderedElement = "gantt\ndateFormat YYYY-MM-DDTHH:mm:ssZ\n"
+	if config := g.initConfig(); len(config) > 0 {
+		directive, _ := json.Marshal(config)
+		renderedElement += fmt.Sprintf("%%%%{init: %s}%%%%\n", directive)
+	}
+	renderedElement += fmt.Sprintf("gantt\ndateFormat %s\n", g.dateFormat())
 	if g.AxisFormat != "" {
 		renderedElement += fmt.Sprintln("axisFormat", g.AxisFormat)
 	}
-	if g.Title != "" {
-		renderedElement += fmt.Sprintln("title", g.Title)
+	if excludes := g.renderExcludes(); excludes != "" {
+		renderedElement += fmt.Sprintln("excludes", excludes)
+	}
+	if g.ExcludeWeekends && g.weekendStart() == time.Friday && g.supports(11) {
+		renderedElement += fmt.Sprintln("weekend friday")
+	}
+	if title := g.title(); title != "" {
+		renderedElement += fmt.Sprintln("title", title)
 	}
+	needID := g.tasksNeedingID()
+	var prev *Task
 	for _, t := range g.tasks {
-		renderedElement += t.String()
+		renderedElement += t.render(prev, needID[t])
+		prev = t
 	}
 	for _, s := range g.sections {
-		renderedElement += s.String()
+		var text string
+		text, prev = s.render(prev, needID)
+		renderedElement += text
 	}
+	if len(g.markers) > 0 {
+		renderedElement += fmt.Sprintln("section", MarkersSection)
+		for _, m := range g.markers {
+			renderedElement += m.String()
+		}
+	}
+	renderedElement += g.renderClicks()
 	return
 }
 
+// title returns the Title to render. If there are no Tasks (markers aside) and
+// EmptyPlaceholder is set, e.g. to "(no data)" for a diagram bound to a query
+// without results, the placeholder is rendered as title or appended to it,
+// so the diagram doesn't render blank.
+func (g *Gantt) title() string {
+	if g.EmptyPlaceholder == "" || len(g.tasksMap) > 0 {
+		return g.Title
+	}
+	if g.Title == "" {
+		return g.EmptyPlaceholder
+	}
+	return g.Title + " " + g.EmptyPlaceholder
+}
+
+// Warnings returns what String downgrades or drops to stay within the
+// CompatMode: a Friday/Saturday weekend is excluded day by day for versions
+// before mermaid 11, the Compact display mode and Tasks starting after
+// several Dependencies (of which only the one ending last is rendered) need
+// mermaid 10. If nothing is affected, an empty slice is returned.
+func (g *Gantt) Warnings() (warnings []string) {
+	warnings = []string{}
+	if g.ExcludeWeekends && g.weekendStart() == time.Friday && !g.supports(11) {
+		warnings = append(warnings,
+			"weekend friday is rendered as excluded friday and saturday")
+	}
+	if g.Compact && !g.supports(10) {
+		warnings = append(warnings, "the Compact display mode is dropped")
+	}
+	if g.supports(10) {
+		return warnings
+	}
+	for _, t := range g.orderedTasks() {
+		if len(t.Dependencies()) > 1 {
+			warnings = append(warnings, fmt.Sprintf(
+				"Task %s is rendered after %s only, the Dependency ending last",
+				t.id, t.latestDependency(make(resolvedDates)).id))
+		}
+	}
+	return warnings
+}
+
+// StringSafe renders the whole diagram like String does, but returns an error
+// instead if a click added via AddClick references an unknown Task ID or a
+// Task whose ID isn't rendered (see AddClick) or if BarHeight, FontSize or
+// SectionFontSize is negative. String renders these
+// sizes to the init directive if they are positive and ignores them otherwise.
+func (g *Gantt) StringSafe() (renderedElement string, err error) {
+	if g.BarHeight < 0 || g.FontSize < 0 || g.SectionFontSize < 0 {
+		return "", fmt.Errorf("StringSafe: BarHeight %d, FontSize %d and "+
+			"SectionFontSize %d must not be negative", g.BarHeight, g.FontSize,
+			g.SectionFontSize)
+	}
+	var first *Task
+	if tasks := g.orderedTasks(); len(tasks) > 0 {
+		first = tasks[0]
+	}
+	for _, c := range g.clicks {
+		t := g.tasksMap[c.id]
+		if t == nil {
+			return "", fmt.Errorf("StringSafe: click references unknown Task %s",
+				c.id)
+		}
+		if t == first && t.Start == nil && t.After == nil {
+			return "", fmt.Errorf("StringSafe: click references Task %s, whose "+
+				"ID isn't rendered without Start or After as first Task", c.id)
+		}
+	}
+	return g.String(), nil
+}
+
+// tasksNeedingID returns the Tasks that need their ID rendered since other
+// Tasks reference them, or all Tasks if AlwaysRenderIDs is set. A Task without
+// Start and After that needs its ID is rendered to start after the Task before
+// it (see Task's render), which therefore needs its ID, too.
+func (g *Gantt) tasksNeedingID() map[*Task]bool {
+	needID := make(map[*Task]bool)
+	for _, t := range g.tasksMap {
+		if g.AlwaysRenderIDs {
+			needID[t] = true
+		}
+		for _, dep := range t.Dependencies() {
+			needID[dep] = true
+		}
+		if len(t.classes) > 0 {
+			// classes are only valid in front of an ID
+			needID[t] = true
+		}
+	}
+	for _, c := range g.clicks {
+		if t := g.tasksMap[c.id]; t != nil {
+			needID[t] = true
+		}
+	}
+	// backwards, so chains of Tasks without Start and After are marked, too
+	tasks := g.orderedTasks()
+	for i := len(tasks) - 1; i > 0; i-- {
+		if t := tasks[i]; needID[t] && t.Start == nil && t.After == nil {
+			needID[tasks[i-1]] = true
+		}
+	}
+	return needID
+}
+
+// initConfig collects the configuration rendered to the init directive, an
+// empty map means no directive is needed.
+func (g *Gantt) initConfig() map[string]interface{} {
+	config := make(map[string]interface{})
+	settings := make(map[string]interface{})
+	rules := []string{}
+	if css, styles := g.bandColorCSS(); css != "" {
+		rules = append(rules, css)
+		settings["numberSectionStyles"] = styles
+	}
+	if g.Compact && g.supports(10) {
+		settings["displayMode"] = "compact"
+	}
+	for key, px := range map[string]int{"barHeight": g.BarHeight,
+		"fontSize": g.FontSize, "sectionFontSize": g.SectionFontSize} {
+		if px > 0 {
+			settings[key] = px
+		}
+	}
+	if len(settings) > 0 {
+		config["gantt"] = settings
+	}
+	if css := g.classDefCSS(); css != "" {
+		rules = append(rules, css)
+	}
+	if len(rules) > 0 {
+		config["themeCSS"] = strings.Join(rules, " ")
+	}
+	return config
+}
+
+// classDefCSS renders the CSS of all class definitions sorted by class name.
+func (g *Gantt) classDefCSS() string {
+	names := make([]string, 0, len(g.classDefs))
+	for name := range g.classDefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rules := make([]string, len(names))
+	for i, name := range names {
+		rules[i] = fmt.Sprintf(".%s { %s }", name, g.classDefs[name])
+	}
+	return strings.Join(rules, " ")
+}
+
+// mermaid's default numberSectionStyles, its themes only fill these classes
+const defaultSectionStyles = 4
+
+// bandColorCSS renders the CSS for the band colors of all Sections and returns
+// the number of section styles needed to give each Section its own CSS class.
+// Mermaid assigns the classes section0, section1, ... in the order the
+// sections appear, where the Section-less Tasks form the first one and
+// Sections without Tasks don't appear at all, unless KeepEmptySections is set.
+// Classes beyond defaultSectionStyles have no fill, so more styles are only
+// used if each of those sections has a band color, otherwise mermaid cycles
+// through the default ones and the later band colors are dropped.
+func (g *Gantt) bandColorCSS() (css string, styles int) {
+	colors := []string{}
+	if len(g.tasks) > 0 {
+		colors = append(colors, "")
+	}
+	for _, s := range g.sections {
+		if len(s.tasks) == 0 && !g.KeepEmptySections {
+			continue
+		}
+		colors = append(colors, s.bandColor)
+	}
+	styles = len(colors)
+	if styles > defaultSectionStyles {
+		for _, color := range colors[defaultSectionStyles:] {
+			if color == "" {
+				styles = defaultSectionStyles
+				break
+			}
+		}
+	}
+	rules := []string{}
+	for i, color := range colors[:styles] {
+		if color != "" {
+			rules = append(rules, fmt.Sprintf(".section%d { fill: %s; }", i,
+				color))
+		}
+	}
+	return strings.Join(rules, " "), styles
+}
+
+// dateFormat returns the DateFormat in use, defaulting to DateFormatRFC3339.
+func (g *Gantt) dateFormat() dateFormat {
+	if g.DateFormat == "" {
+		return DateFormatRFC3339
+	}
+	return g.DateFormat
+}
+
+// dateFormatName describes the DateFormat in use for error messages.
+func (g *Gantt) dateFormatName() string {
+	if g.dateFormat() == DateFormatRFC3339 {
+		return "RFC3339"
+	}
+	return fmt.Sprintf("DateFormat %q", string(g.DateFormat))
+}
+
+// formatDate renders a date according to the DateFormat in use.
+func (g *Gantt) formatDate(date time.Time) string {
+	return date.Format(g.dateFormat().layout())
+}
+
+// parseDate parses a date according to the DateFormat in use.
+func (g *Gantt) parseDate(date string) (time.Time, error) {
+	return time.Parse(g.dateFormat().layout(), date)
+}
+
+// Helperfunction to render the comma separated excludes list.
+func (g *Gantt) renderExcludes() string {
+	excludes := []string{}
+	if g.ExcludeWeekends {
+		if start := g.weekendStart(); start == time.Saturday ||
+			start == time.Friday && g.supports(11) {
+			excludes = append(excludes, "weekends")
+		} else {
+			// a weekend mermaid doesn't know (mermaid 11 added weekend
+			// friday), exclude its days explicitly
+			for _, d := range g.weekendDays() {
+				excludes = append(excludes, strings.ToLower(d.String()))
+			}
+		}
+	}
+	for _, d := range g.ExcludeWeekdays {
+		excludes = append(excludes, strings.ToLower(d.String()))
+	}
+	for _, d := range g.ExcludeDates {
+		// mermaid compares the excluded dates to days formatted by dateFormat
+		day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())
+		excludes = append(excludes, g.formatDate(day))
+	}
+	return strings.Join(excludes, ", ")
+}
+
+// WriteTo renders the whole diagram like String does and writes it to w, using
+// the configured LineEnding for every line and a leading UTF-8 byte order mark
+// if BOM is set. It implements io.WriterTo.
+func (g *Gantt) WriteTo(w io.Writer) (n int64, err error) {
+	text := g.String()
+	if g.LineEnding != "" && g.LineEnding != LineEndingLF {
+		text = strings.ReplaceAll(text, "\n", string(g.LineEnding))
+	}
+	if g.BOM {
+		text = utf8BOM + text
+	}
+	written, err := io.WriteString(w, text)
+	return int64(written), err
+}
+
+// WriteFile creates or truncates the file at the given path and writes the
+// rendered diagram to it via WriteTo.
+func (g *Gantt) WriteFile(path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = g.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
 // Structs for JSON encode
 type mermaidJSON struct {
 	Theme string `json:"theme"`
@@ -107,11 +543,24 @@ func (g *Gantt) LiveURL() (url string) {
 	data, _ := json.Marshal(dataJSON{
 		Code: g.String(), Mermaid: mermaidJSON{Theme: "default"},
 	})
+	return liveURL + base64.URLEncoding.EncodeToString(deflate(data))
+}
+
+// zlib writers reused by deflate, allocating one per call dominates LiveURL
+var zlibWriters = sync.Pool{New: func() interface{} {
+	w, _ := zlib.NewWriterLevel(nil, zlib.BestCompression)
+	return w
+}}
+
+// deflate compresses data with zlib like pako does for the live editor.
+func deflate(data []byte) []byte {
 	var b bytes.Buffer
-	w, _ := zlib.NewWriterLevel(&b, zlib.BestCompression)
+	w := zlibWriters.Get().(*zlib.Writer)
+	w.Reset(&b)
 	w.Write(data)
 	w.Close()
-	return liveURL + base64.URLEncoding.EncodeToString(b.Bytes())
+	zlibWriters.Put(w)
+	return b.Bytes()
 }
 
 // ViewInBrowser uses the URL generated by Gantt's LiveURL method and opens
@@ -147,12 +596,47 @@ func (g *Gantt) AddSection(id string) (newSection *Section, err error) {
 	return
 }
 
+// TaskSpec describes a Task for Gantt's AddSectionWithTasks method.
+type TaskSpec struct {
+	ID       string      // The Task's ID, see AddTask.
+	Title    string      // Optional title, the ID is rendered if empty.
+	Start    interface{} // Optional start, see Task's SetStart.
+	Duration interface{} // Optional duration, see Task's SetDuration.
+	Status   Status      // Optional Status setting the Task's flags.
+}
+
+// AddSectionWithTasks is used to add a new Section with the Tasks described by
+// specs in the given order, which saves a call per field for typical phases.
+// Starts may refer to Tasks of earlier specs. If the Section ID already
+// exists, nothing is created and an error is returned. Otherwise the Section
+// and all valid Tasks are created and the failures of the other Tasks are
+// returned as one error naming the index of each failing spec.
+func (g *Gantt) AddSectionWithTasks(id string, specs []TaskSpec) (
+	newSection *Section, err error) {
+	newSection, err = g.AddSection(id)
+	if err != nil {
+		return nil, fmt.Errorf("AddSectionWithTasks: %s", err)
+	}
+	problems := []string{}
+	for i, spec := range specs {
+		if err := newSection.addTaskSpec(spec); err != nil {
+			problems = append(problems,
+				fmt.Sprintf("task %d (%s): %s", i, spec.ID, err))
+		}
+	}
+	if len(problems) > 0 {
+		return newSection, fmt.Errorf("AddSectionWithTasks: %s",
+			strings.Join(problems, "; "))
+	}
+	return newSection, nil
+}
+
 // AddTask is used to add a new Task to this Gantt diagram. If the provided ID
 // already exists or is invalid, no new Task is created and an error is
 // returned. The ID can later be used to look up the created Task using Gantt's
 // GetTask method. Optional initializer parameters can be given in the order
-// Title, Duration, Start, Critical, Active, Done. Duration and Start are set
-// via Task's SetDuration and SetStart respectively.
+// Title, Duration, Start, Critical, Active, Done, Milestone. Duration and Start
+// are set via Task's SetDuration and SetStart respectively.
 func (g *Gantt) AddTask(id string, init ...interface{}) (newTask *Task, err error) {
 	newTask, err = taskNew(id, g, nil, init)
 	if err != nil {
@@ -163,8 +647,143 @@ func (g *Gantt) AddTask(id string, init ...interface{}) (newTask *Task, err erro
 	return
 }
 
+// AddMilestone is used to add a new milestone Task to this Gantt diagram. The
+// Task starts at the given date (see DateFormat), has a Duration of 0 and the
+// Milestone flag set. If the provided ID already exists or is invalid or the
+// date can't be parsed, no new Task is created and an error is returned.
+func (g *Gantt) AddMilestone(id, title, date string) (newTask *Task, err error) {
+	start, err := g.parseMilestoneDate(date)
+	if err != nil {
+		return nil, err
+	}
+	newTask, err = g.AddTask(id, title, time.Duration(0), start)
+	if err != nil {
+		return nil, err
+	}
+	newTask.Milestone = true
+	return
+}
+
+// AddGate is used to add a new milestone Task to this Gantt diagram that is
+// reached when all given Tasks have ended, e.g. a phase gate. Unlike
+// AddMilestone the date isn't fixed but follows the latest end of the Tasks,
+// see Task's SetAfter. If the provided ID already exists or is invalid or no
+// Tasks are given, no new Task is created and an error is returned.
+func (g *Gantt) AddGate(id, title string, after ...*Task) (newTask *Task, err error) {
+	newTask, err = gateNew(id, g, nil, title, after)
+	if err != nil {
+		return nil, err
+	}
+	g.tasksMap[id] = newTask
+	g.tasks = append(g.tasks, newTask)
+	return newTask, nil
+}
+
+// Private constructor for the gates of AddGate.
+func gateNew(id string, g *Gantt, s *Section, title string, after []*Task) (
+	*Task, error) {
+	gate, err := taskNew(id, g, s, []interface{}{title, time.Duration(0)})
+	if err != nil {
+		return nil, fmt.Errorf("AddGate: %s", err)
+	}
+	if err := gate.SetAfter(after...); err != nil {
+		return nil, fmt.Errorf("AddGate: %s",
+			strings.TrimPrefix(err.Error(), "SetAfter: "))
+	}
+	gate.Milestone = true
+	return gate, nil
+}
+
+// MarkersSection is the title of the section synthesized for markers, see
+// Gantt's AddMarker method.
+const MarkersSection = "Markers"
+
+// AddMarker is used to annotate the Gantt diagram with a fixed key date like a
+// release or a code freeze. Since mermaid has no vertical markers, this is
+// approximated by a milestone at the given date (see DateFormat) in a section
+// titled MarkersSection that is rendered after all other Sections. Markers are
+// not part of ListTasks or ListSections (use ListMarkers) and get generated
+// IDs (_marker1, _marker2, ...). An error is returned if the date can't be
+// parsed.
+func (g *Gantt) AddMarker(label, date string) (err error) {
+	start, err := g.parseDate(date)
+	if err != nil {
+		return fmt.Errorf(`AddMarker: "%s" is no %s date`, date,
+			g.dateFormatName())
+	}
+	id := ""
+	for i := len(g.markers) + 1; id == "" || g.tasksMap[id] != nil; i++ {
+		id = fmt.Sprintf("_marker%d", i)
+	}
+	marker, err := taskNew(id, g, nil,
+		[]interface{}{label, time.Duration(0), start})
+	if err != nil {
+		return err
+	}
+	marker.Milestone = true
+	g.markers = append(g.markers, marker)
+	return nil
+}
+
+// click is a click directive added via Gantt's AddClick method.
+type click struct {
+	id  string // ID of the clicked Task
+	url string // URL to open
+}
+
+// AddClick makes the Task with the given ID a link to url. The click lines are
+// rendered in an interactions block after all Sections, sorted by Task ID, and
+// the Task's ID is rendered, whether it is contained in a Section or the Gantt
+// itself. Like for classes (see Task's AddClass), a Task without Start and
+// After that comes first in the diagram has no ID position, so its click has
+// no effect. Mermaid gantt has no click tooltips, so none can be set. Unknown
+// Task IDs and such first Tasks can't be detected before rendering since the
+// Tasks may be added later, use StringSafe to have them reported as an error.
+func (g *Gantt) AddClick(taskID, url string) {
+	g.clicks = append(g.clicks, click{id: taskID, url: url})
+}
+
+// renderClicks renders the interactions block of all clicks.
+func (g *Gantt) renderClicks() string {
+	clicks := append([]click(nil), g.clicks...)
+	sort.SliceStable(clicks, func(i, j int) bool {
+		return clicks[i].id < clicks[j].id
+	})
+	text := ""
+	for _, c := range clicks {
+		text += fmt.Sprintf("click %s href \"%s\"\n", c.id,
+			strings.ReplaceAll(c.url, `"`, "%22"))
+	}
+	return text
+}
+
 ////////// get Items ///////////////////////////////////////////////////////////
 
+// SetClassDef defines the CSS (e.g. "fill: #bbf; stroke: #33f") applied to
+// all Tasks carrying the given class, see Task's AddClass. It is rendered as
+// themeCSS via an init directive, renderers without themeCSS support ignore
+// it. An empty css removes the definition. An error is returned if the name
+// is no valid class name.
+func (g *Gantt) SetClassDef(name, css string) (err error) {
+	if !isValidClass(name) {
+		return fmt.Errorf("SetClassDef: invalid class name %q", name)
+	}
+	if css == "" {
+		delete(g.classDefs, name)
+		return nil
+	}
+	if g.classDefs == nil {
+		g.classDefs = make(map[string]string)
+	}
+	g.classDefs[name] = css
+	return nil
+}
+
+// ClassDef returns the CSS defined for the given class via SetClassDef.
+func (g *Gantt) ClassDef(name string) (css string) {
+	return g.classDefs[name]
+}
+
 // GetSection looks up a previously defined Section by its ID.
 // If this ID doesn't exist, nil is returned.
 // Use Gantt's AddSection to create new Sections.
@@ -199,8 +818,17 @@ func (g *Gantt) ListLocalTasks() (localTasks []*Task) {
 	return
 }
 
+// ListMarkers returns a slice of the milestones previously added to this
+// Gantt diagram via AddMarker in the order they were defined.
+func (g *Gantt) ListMarkers() (markers []*Task) {
+	markers = make([]*Task, len(g.markers))
+	copy(markers, g.markers)
+	return
+}
+
 // ListTasks returns a slice of all Tasks previously added to this
 // Gantt diagram and all of its Sections in alphabetic order by ID.
+// Markers are not included, see ListMarkers.
 func (g *Gantt) ListTasks() (allTasks []*Task) {
 	allTasks = make([]*Task, 0, len(g.tasksMap))
 	for _, v := range g.tasksMap {
@@ -212,3 +840,456 @@ func (g *Gantt) ListTasks() (allTasks []*Task) {
 	})
 	return
 }
+
+////////// date math ///////////////////////////////////////////////////////////
+
+// TaskInterval is the resolved time interval of a Task, see Gantt's Timeline.
+// End is exclusive, e.g. a Task of one day starting at midnight ends at the
+// following midnight.
+type TaskInterval struct {
+	Task  *Task     // The Task this interval belongs to
+	Start time.Time // Resolved start of the Task
+	End   time.Time // Resolved end of the Task, stretched over excluded days
+	Row   int       // Row of the Task within its Section, starting at 0
+}
+
+// Timeline resolves the intervals of all Tasks in the order they are rendered,
+// markers are not included. Starts are resolved from Start or along After
+// references and Tasks are stretched over the days excluded by the Exclude
+// settings like mermaid does. Each Task gets its own row within its Section
+// (the Section-less Tasks form one, too), unless Compact is set: Then Tasks
+// that don't overlap share rows, they are placed by descending priority (see
+// Task's SetPriority), then by start and then in the order they are rendered,
+// each one into the first row it fits in. An error is returned if a Task's
+// start can't be resolved or After references form a cycle.
+func (g *Gantt) Timeline() (intervals []TaskInterval, err error) {
+	intervals = []TaskInterval{}
+	rows := make(map[*Section]int)
+	resolved := make(resolvedDates)
+	for _, t := range g.orderedTasks() {
+		start, end, err := t.resolve(make(map[*Task]bool), resolved)
+		if err != nil {
+			return nil, fmt.Errorf("Timeline: %s", err)
+		}
+		intervals = append(intervals,
+			TaskInterval{Task: t, Start: start, End: end, Row: rows[t.section]})
+		rows[t.section]++
+	}
+	if g.Compact {
+		packRows(intervals)
+	}
+	return intervals, nil
+}
+
+// packRows assigns the rows of compact mode to the intervals, see Timeline.
+func packRows(intervals []TaskInterval) {
+	order := make([]int, len(intervals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := intervals[order[a]], intervals[order[b]]
+		if x.Task.priority != y.Task.priority {
+			return x.Task.priority > y.Task.priority
+		}
+		return x.Start.Before(y.Start)
+	})
+	rows := make(map[*Section][][]TaskInterval)
+	for _, i := range order {
+		interval := &intervals[i]
+		bands := rows[interval.Task.section]
+		row := 0
+		for ; row < len(bands); row++ {
+			if !overlapsAny(bands[row], *interval) {
+				break
+			}
+		}
+		if row == len(bands) {
+			bands = append(bands, nil)
+		}
+		bands[row] = append(bands[row], *interval)
+		rows[interval.Task.section] = bands
+		interval.Row = row
+	}
+}
+
+// Helperfunction to check whether interval overlaps any of intervals.
+func overlapsAny(intervals []TaskInterval, interval TaskInterval) bool {
+	for _, i := range intervals {
+		if i.Start.Before(interval.End) && interval.Start.Before(i.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Span returns the earliest start and the latest end of all Tasks, markers
+// are not included. See Timeline for details on how the Tasks are resolved.
+// An error is returned if there are no Tasks or they can't be resolved.
+func (g *Gantt) Span() (start, end time.Time, err error) {
+	intervals, err := g.Timeline()
+	if err != nil {
+		return start, end, fmt.Errorf("Span: %s", err)
+	}
+	if len(intervals) == 0 {
+		return start, end, fmt.Errorf("Span: no Tasks defined")
+	}
+	start, end = intervals[0].Start, intervals[0].End
+	for _, i := range intervals[1:] {
+		if i.Start.Before(start) {
+			start = i.Start
+		}
+		if i.End.After(end) {
+			end = i.End
+		}
+	}
+	return start, end, nil
+}
+
+// TotalEffort returns the sum of the Durations of all Tasks regardless of how
+// they overlap, as opposed to the calendar time of Span. Tasks without
+// Duration count as one day like they are rendered, milestones and markers are
+// not included. See Section's TotalEffort for the effort per Section. An error
+// is returned if there are no Tasks.
+func (g *Gantt) TotalEffort() (effort time.Duration, err error) {
+	tasks := g.orderedTasks()
+	if len(tasks) == 0 {
+		return 0, fmt.Errorf("TotalEffort: no Tasks defined")
+	}
+	return sumEffort(tasks), nil
+}
+
+// sumEffort sums the Durations of all non-milestone Tasks.
+func sumEffort(tasks []*Task) time.Duration {
+	effort := time.Duration(0)
+	for _, t := range tasks {
+		if !t.Milestone {
+			effort += t.duration()
+		}
+	}
+	return effort
+}
+
+// CriticalPath returns the chain of Tasks that determines the end of the
+// diagram: the Task ending last (of several ending at the same time the first
+// one rendered, unless a later one like a gate depends on it) and the Tasks it
+// depends on, ordered from the first to the last one. Of several Dependencies
+// the one ending last is followed. See Timeline for details on how the Tasks
+// are resolved. An error is returned if a Task can't be resolved or the
+// Dependencies form a cycle. Without Tasks an empty slice is returned.
+func (g *Gantt) CriticalPath() (path []*Task, err error) {
+	intervals, err := g.Timeline()
+	if err != nil {
+		return nil, fmt.Errorf("CriticalPath: %s", err)
+	}
+	path = []*Task{}
+	if len(intervals) == 0 {
+		return path, nil
+	}
+	resolved := make(resolvedDates)
+	last := intervals[0]
+	for _, i := range intervals[1:] {
+		// zero duration Tasks like gates ending with their dependency win
+		if i.End.After(last.End) || i.End.Equal(last.End) &&
+			i.Task.followsLatest(last.Task, resolved) {
+			last = i
+		}
+	}
+	// cycles were already ruled out by Timeline
+	for t := last.Task; t != nil; t = t.latestDependency(resolved) {
+		path = append([]*Task{t}, path...)
+		if t.Start != nil {
+			break
+		}
+	}
+	return path, nil
+}
+
+// CriticalSet returns the Tasks of CriticalPath as a set, e.g. to look up
+// whether a Task is critical while iterating over the Timeline. Errors are
+// returned like for CriticalPath, including dependency cycles.
+func (g *Gantt) CriticalSet() (critical map[*Task]bool, err error) {
+	path, err := g.CriticalPath()
+	if err != nil {
+		return nil, fmt.Errorf("CriticalSet: %s", err)
+	}
+	critical = make(map[*Task]bool, len(path))
+	for _, t := range path {
+		critical[t] = true
+	}
+	return critical, nil
+}
+
+// followsLatest reports whether t is reached from x by following the latest
+// Dependencies, see latestDependency.
+func (t *Task) followsLatest(x *Task, resolved resolvedDates) bool {
+	for dep := t.latestDependency(resolved); dep != nil; {
+		if dep == x {
+			return true
+		}
+		if dep.Start != nil {
+			break
+		}
+		dep = dep.latestDependency(resolved)
+	}
+	return false
+}
+
+// latestDependency returns the Dependency ending last (the first one if
+// several end at the same time), nil without Dependencies. The Dependencies
+// are resolved via the given cache, see resolvedDates.
+func (t *Task) latestDependency(resolved resolvedDates) *Task {
+	var latest *Task
+	var latestEnd time.Time
+	for _, dep := range t.Dependencies() {
+		_, end, _ := dep.resolve(make(map[*Task]bool), resolved)
+		if latest == nil || end.After(latestEnd) {
+			latest, latestEnd = dep, end
+		}
+	}
+	return latest
+}
+
+// MarkCriticalPath sets the Critical flag of all Tasks on the CriticalPath and
+// clears it for all other Tasks, so the crit styling reflects the actual
+// critical path after schedule changes. Markers are not touched. An error is
+// returned and no flag is changed if the CriticalPath can't be computed, e.g.
+// because of a dependency cycle.
+func (g *Gantt) MarkCriticalPath() (err error) {
+	path, err := g.CriticalPath()
+	if err != nil {
+		return fmt.Errorf("MarkCriticalPath: %s", err)
+	}
+	critical := make(map[*Task]bool)
+	for _, t := range path {
+		critical[t] = true
+	}
+	for _, t := range g.orderedTasks() {
+		t.Critical = critical[t]
+	}
+	return nil
+}
+
+// ValidateDependencies checks the Dependencies of all Tasks and returns a
+// single error listing all problems found, nil if there are none. Reported are
+// references to Tasks that are not part of this Gantt, dependency cycles with
+// the chain of Task IDs forming them and Tasks whose Start (which wins over
+// After) lies before the end of a Task they depend on. Markers are not
+// checked.
+func (g *Gantt) ValidateDependencies() (err error) {
+	problems := []string{}
+	// depth first search for cycles, a Start ends a chain of Dependencies
+	done := make(map[*Task]bool)
+	index := make(map[*Task]int)
+	chain := []*Task{}
+	var visit func(x *Task)
+	visit = func(x *Task) {
+		if done[x] || x.Start != nil {
+			return
+		}
+		if i, onChain := index[x]; onChain {
+			ids := []string{}
+			for _, c := range chain[i:] {
+				ids = append(ids, c.id)
+			}
+			problems = append(problems, fmt.Sprintf(
+				"dependency cycle %s -> %s", strings.Join(ids, " -> "), x.id))
+			return
+		}
+		index[x] = len(chain)
+		chain = append(chain, x)
+		for _, dep := range x.Dependencies() {
+			visit(dep)
+		}
+		chain = chain[:len(chain)-1]
+		delete(index, x)
+		done[x] = true
+	}
+	for _, t := range g.orderedTasks() {
+		for _, dep := range t.Dependencies() {
+			if g.tasksMap[dep.id] != dep {
+				problems = append(problems, fmt.Sprintf(
+					"Task %s depends on unknown Task %s", t.id, dep.id))
+			}
+			if t.Start == nil {
+				continue
+			}
+			_, end, err := dep.resolveDates()
+			if err == nil && end.After(*t.Start) {
+				problems = append(problems, fmt.Sprintf(
+					"Task %s starts at %s before Task %s ends at %s", t.id,
+					g.formatDate(*t.Start), dep.id, g.formatDate(end)))
+			}
+		}
+		visit(t)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("ValidateDependencies: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Shift moves the whole plan by d, e.g. when the project start slips: The
+// explicit Start of every Task, milestone and marker is moved by d, Tasks
+// starting after other Tasks follow automatically. ExcludeDates are kept, as
+// holidays don't move with the plan. If a shifted date can't be written in the
+// DateFormat (e.g. shifting by 36h with DateFormatDate, which has no time of
+// day), an error is returned and nothing is changed.
+func (g *Gantt) Shift(d time.Duration) (err error) {
+	tasks := append(g.orderedTasks(), g.markers...)
+	starts := make([]*time.Time, len(tasks))
+	for i, t := range tasks {
+		if t.Start == nil {
+			continue
+		}
+		start := t.Start.Add(d)
+		// dates are written without zone, so they are read in the start's one
+		parsed, err := time.ParseInLocation(g.dateFormat().layout(),
+			g.formatDate(start), start.Location())
+		if err != nil || !parsed.Equal(start) {
+			return fmt.Errorf("Shift: start %s of Task %s can't be written as %s",
+				start.Format(time.RFC3339), t.id, g.dateFormatName())
+		}
+		starts[i] = &start
+	}
+	for i, t := range tasks {
+		if starts[i] != nil {
+			t.Start = starts[i]
+		}
+	}
+	return nil
+}
+
+// DependencyFlowchart builds a Flowchart of the dependencies between the
+// Tasks, which the Gantt diagram itself doesn't draw: One Node per Task in the
+// order they are rendered, with the Task's ID as ID and its Title as text, and
+// an Edge from each Task to the Tasks following it (see After and SetAfter).
+// Milestones are rendered as rhombus Nodes, markers are not included.
+func (g *Gantt) DependencyFlowchart() (fc *flowchart.Flowchart) {
+	fc = flowchart.NewFlowchart()
+	fc.Direction = flowchart.DirectionLeftRight
+	tasks := g.orderedTasks()
+	// looked up by Task, other Gantt diagrams may use the same IDs
+	nodes := make(map[*Task]*flowchart.Node, len(tasks))
+	for _, t := range tasks {
+		n := fc.AddNode(t.id)
+		nodes[t] = n
+		if t.Title != "" {
+			n.AddLines(t.Title)
+		}
+		if t.Milestone {
+			n.Shape = flowchart.NShapeRhombus
+		}
+	}
+	for _, t := range tasks {
+		for _, dep := range t.Dependencies() {
+			from, local := nodes[dep]
+			if !local {
+				continue // dependency on a Task of another Gantt diagram
+			}
+			fc.AddEdge(from, nodes[t])
+		}
+	}
+	return
+}
+
+// orderedTasks returns all Tasks in the order they are rendered, markers are
+// not included.
+func (g *Gantt) orderedTasks() []*Task {
+	tasks := append([]*Task(nil), g.tasks...)
+	for _, s := range g.sections {
+		tasks = append(tasks, s.tasks...)
+	}
+	return tasks
+}
+
+// weekendDays returns the sorted, distinct WeekendDays in use, defaulting to
+// saturday and sunday.
+func (g *Gantt) weekendDays() []time.Weekday {
+	if len(g.WeekendDays) == 0 {
+		return []time.Weekday{time.Sunday, time.Saturday}
+	}
+	set := make(map[time.Weekday]bool)
+	days := []time.Weekday{}
+	for _, d := range g.WeekendDays {
+		if !set[d] {
+			set[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	return days
+}
+
+// weekendStart returns the first day of the weekend if the WeekendDays form a
+// weekend mermaid supports via its weekend directive (friday and saturday or
+// saturday and sunday), -1 otherwise.
+func (g *Gantt) weekendStart() time.Weekday {
+	days := g.weekendDays()
+	switch {
+	case len(days) != 2:
+		return -1
+	case days[0] == time.Friday && days[1] == time.Saturday:
+		return time.Friday
+	case days[0] == time.Sunday && days[1] == time.Saturday:
+		return time.Saturday
+	}
+	return -1
+}
+
+// isExcluded reports whether the given day is excluded by the Gantt's
+// ExcludeWeekends, ExcludeWeekdays or ExcludeDates settings.
+func (g *Gantt) isExcluded(day time.Time) bool {
+	wd := day.Weekday()
+	if g.ExcludeWeekends {
+		for _, d := range g.weekendDays() {
+			if d == wd {
+				return true
+			}
+		}
+	}
+	for _, d := range g.ExcludeWeekdays {
+		if d == wd {
+			return true
+		}
+	}
+	y, m, d := day.Date()
+	for _, x := range g.ExcludeDates {
+		xy, xm, xd := x.In(day.Location()).Date()
+		if xy == y && xm == m && xd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExcludes returns an error if the excludes leave no working day.
+func (g *Gantt) validateExcludes() error {
+	weekdays := make(map[time.Weekday]bool)
+	if g.ExcludeWeekends {
+		for _, d := range g.weekendDays() {
+			weekdays[d] = true
+		}
+	}
+	for _, d := range g.ExcludeWeekdays {
+		weekdays[d] = true
+	}
+	if len(weekdays) == 7 {
+		return fmt.Errorf("all days of the week are excluded")
+	}
+	return nil
+}
+
+// extendOverExcludes moves end one day further for every excluded day between
+// start and end, the same way mermaid does when rendering a Task.
+func (g *Gantt) extendOverExcludes(start, end time.Time) time.Time {
+	if g.validateExcludes() != nil {
+		return end
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if g.isExcluded(day) {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	return end
+}
@@ -1,11 +1,13 @@
 package gantt_test
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/Heiko-san/mermaidgen/gantt"
+	"github.com/StephenBrown2/mermaidgen/gantt"
 )
 
 // Defining and rendering a gantt diagram
@@ -97,6 +99,66 @@ func ExampleGantt_iterateSectionsAndTasks() {
 	//t2
 }
 
+// Using a non-ISO input date format independent of the axis display
+func ExampleGantt_dateFormat() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDayMonthYear
+	g.AxisFormat = "%b %d"
+	g.ExcludeDates = []time.Time{time.Date(2019, 6, 26, 0, 0, 0, 0, time.UTC)}
+	// SetStart and AddMilestone parse dates using DateFormat
+	g.AddTask("t1", "a task", "48h", "20/06/2019")
+	_, err := g.AddTask("t2", "broken", "48h", "2019-06-20")
+	fmt.Println(err)
+	g.AddMilestone("m1", "release", "24/06/2019")
+	fmt.Print(g)
+	//Output:
+	//SetStart: "2019-06-20" is neither DateFormat "DD/MM/YYYY" nor a valid Task ID
+	//gantt
+	//dateFormat DD/MM/YYYY
+	//axisFormat %b %d
+	//excludes 26/06/2019
+	//a task : t1, 20/06/2019, 172800s
+	//release : milestone, m1, 24/06/2019, 0s
+}
+
+func TestIsValidAxisFormat(t *testing.T) {
+	for _, f := range []string{"", "%Y-%m-%d", "week %V", "%-d.%_m.", "100%%",
+		string(gantt.FormatWeekNumber), string(gantt.FormatDateTime24WithSeconds)} {
+		assert(t, gantt.IsValidAxisFormat(f), "%q should be valid", f)
+	}
+	for _, f := range []string{"%", "%i", "%-", "week %v"} {
+		assert(t, !gantt.IsValidAxisFormat(f), "%q should be invalid", f)
+	}
+}
+
+func TestGanttDateFormatRoundTrip(t *testing.T) {
+	formats := []struct {
+		set  func(g *gantt.Gantt)
+		date string
+	}{
+		{func(g *gantt.Gantt) { g.DateFormat = gantt.DateFormatRFC3339 },
+			"2019-06-20T09:15:30+02:00"},
+		{func(g *gantt.Gantt) { g.DateFormat = gantt.DateFormatDateTime },
+			"2019-06-20 09:15"},
+		{func(g *gantt.Gantt) { g.DateFormat = gantt.DateFormatDate },
+			"2019-06-20"},
+		{func(g *gantt.Gantt) { g.DateFormat = gantt.DateFormatDayMonthYear },
+			"20/06/2019"},
+		{func(g *gantt.Gantt) { g.DateFormat = gantt.DateFormatMonthDayYear },
+			"06/20/2019"},
+		{func(g *gantt.Gantt) { g.DateFormat = "D MMM YYYY h:mm A" },
+			"5 Jun 2019 9:15 PM"},
+	}
+	for _, f := range formats {
+		g, _ := gantt.NewGantt()
+		f.set(g)
+		task, err := g.AddTask("t1", "task", "1h", f.date)
+		assert(t, err == nil, "%s: %v", f.date, err)
+		want := fmt.Sprintf("task : t1, %s, 3600s\n", f.date)
+		assert(t, task.String() == want, "got %q, want %q", task.String(), want)
+	}
+}
+
 // Generating URLs for the mermaid live editor
 func ExampleGantt_liveEditorLinks() {
 	g, _ := gantt.NewGantt()
@@ -105,7 +167,7 @@ func ExampleGantt_liveEditorLinks() {
 	g.AddTask("t2", "another task", "2h")
 	// you can also use g.ViewInBrowser() to open the URL in browser directly
 	fmt.Println(g.LiveURL())
-	// Output: https://mermaid.live/view/#pako:eNo0zbEKg0AMgOFXOTJ7EJVazCzFxc3lxCX00lranOClk_jupYWuPz98O1zXKEBw52Q2p8gml3VTNhdCCH4YfNeNfU-qlPM0J3bG-enIWVm4CsvWY-MrHLGl8kQ1ToWrG8Q8J06rLbL9_3P1q1CAyqb8iEA72CL61aPc-P0yOI5PAAAA___xki0z
+	// Output: https://mermaid.live/view/#pako:eNo0zbEKg0AMgOFXOTJ7EJVazCzFxc3lxCX00lranOClk_jupYWuPz98O1zXKEBw52Q2p8gml3VTNhdCCH4YfNeNfU-qlPM0J3bG-enIWVm4CsvWY-MrHLGl8kQ1ToWrG8Q8J06rLbL9_3P1q1CAyqb8iEA72CL61aPc-P0yOI7PAPGSLTM=
 }
 
 // The creation of Gantts, Sections and Tasks may yield errors
@@ -115,6 +177,8 @@ func ExampleGantt_errorHandling() {
 	fmt.Println(g1, err)
 	g2, err := gantt.NewGantt("title", 5)
 	fmt.Println(g2, err)
+	g2, err = gantt.NewGantt("title", "%Y-%i")
+	fmt.Println(g2, err)
 	g, _ := gantt.NewGantt()
 	// same applies to Task creation
 	t1, err := g.AddTask("id1", "my title", "1h50xyz")
@@ -136,6 +200,7 @@ func ExampleGantt_errorHandling() {
 	//Output:
 	//<nil> value for Title was no string
 	//<nil> value for AxisFormat was no axisFormat
+	//<nil> value for AxisFormat is no valid axisFormat
 	//<nil> SetDuration: "1h50xyz" is neither a valid duration nor Task ID
 	//<nil> SetStart: "foobar" is neither RFC3339 nor a valid Task ID
 	//<nil> id already exists
@@ -143,6 +208,408 @@ func ExampleGantt_errorHandling() {
 	//<nil> invalid id
 }
 
+// Writing a gantt diagram for Windows based tools
+func ExampleGantt_WriteTo() {
+	g, _ := gantt.NewGantt("title")
+	s1, _ := g.AddSection("s1")
+	s1.AddTask("t1", "a task", "1h")
+	g.LineEnding = gantt.LineEndingCRLF
+	g.BOM = true
+	var b bytes.Buffer
+	g.WriteTo(&b)
+	fmt.Printf("%q\n", b.String())
+	//Output:
+	//"\ufeffgantt\r\ndateFormat YYYY-MM-DDTHH:mm:ssZ\r\ntitle title\r\nsection s1\r\na task : 3600s\r\n"
+}
+
+func TestGanttWriteDefaults(t *testing.T) {
+	g, _ := gantt.NewGantt("title")
+	g.AddTask("t1")
+	var b bytes.Buffer
+	n, err := g.WriteTo(&b)
+	assert(t, err == nil)
+	assert(t, n == int64(b.Len()))
+	assert(t, b.String() == g.String())
+}
+
+func TestGanttAddMilestone(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	m, err := g.AddMilestone("m1", "gate", "2019-06-21T00:00:00Z")
+	assert(t, err == nil)
+	assert(t, m.Milestone)
+	assert(t, *m.Duration == 0)
+	assert(t, m.Section() == nil)
+	assert(t, g.GetTask("m1") == m)
+	m2, err := g.AddMilestone("m 2", "gate", "2019-06-21T00:00:00Z")
+	assert(t, m2 == nil)
+	assert(t, err != nil)
+	m3, _ := g.AddTask("m3")
+	m3.CopyFields(m)
+	assert(t, m3.Milestone)
+}
+
+// Annotating a gantt diagram with key dates
+func ExampleGantt_AddMarker() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	s, _ := g.AddSection("Development")
+	s.AddTask("dev", "implement", "240h", "2019-07-01")
+	g.AddMarker("code freeze", "2019-07-08")
+	g.AddMarker("release", "2019-07-11")
+	err := g.AddMarker("party", "someday")
+	fmt.Println(err)
+	fmt.Println(len(g.ListTasks()), len(g.ListMarkers()))
+	fmt.Print(g)
+	//Output:
+	//AddMarker: "someday" is no DateFormat "YYYY-MM-DD" date
+	//1 2
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section Development
+	//implement : dev, 2019-07-01, 864000s
+	//section Markers
+	//code freeze : milestone, _marker1, 2019-07-08, 0s
+	//release : milestone, _marker2, 2019-07-11, 0s
+}
+
+// Resolving the Tasks' intervals, the overall span and the critical path
+func ExampleGantt_Timeline() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.ExcludeWeekends = true
+	s, _ := g.AddSection("dev")
+	design, _ := s.AddTask("design", "", "48h", "2019-06-20")
+	backend, _ := s.AddTask("backend", "", "72h", design)
+	s.AddTask("docs", "", "24h", design)
+	s.AddTask("release", "", "24h", backend)
+	g.AddMarker("freeze", "2019-07-15")
+	intervals, _ := g.Timeline()
+	for _, i := range intervals {
+		fmt.Println(i.Task.ID(), i.Start.Format("Mon 01-02"), i.End.Format("Mon 01-02"))
+	}
+	start, end, _ := g.Span()
+	fmt.Println(start.Format("2006-01-02"), end.Format("2006-01-02"))
+	path, _ := g.CriticalPath()
+	for _, t := range path {
+		fmt.Print(t.ID(), ";")
+	}
+	fmt.Println()
+	//Output:
+	//design Thu 06-20 Mon 06-24
+	//backend Mon 06-24 Thu 06-27
+	//docs Mon 06-24 Tue 06-25
+	//release Thu 06-27 Fri 06-28
+	//2019-06-20 2019-06-28
+	//design;backend;release;
+}
+
+// Keeping the crit flags in sync with the critical path
+func ExampleGantt_MarkCriticalPath() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	design, _ := g.AddTask("design", "", "48h", "2019-06-20")
+	g.AddTask("docs", "", "72h", design)
+	// a stale crit flag
+	g.AddTask("qa", "", "24h", design, true)
+	g.MarkCriticalPath()
+	fmt.Print(g)
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//design : crit, design, 2019-06-20, 172800s
+	//docs : crit, docs, after design, 259200s
+	//qa : qa, after design, 86400s
+}
+
+// Packing Tasks into rows in compact mode
+func ExampleGantt_compact() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.Compact = true
+	s, _ := g.AddSection("s1")
+	s.AddTask("x", "", "24h", "2019-06-20")
+	y, _ := s.AddTask("y", "", "48h", "2019-06-20")
+	s.AddTask("z", "", "24h", "2019-06-21")
+	printRows := func() {
+		intervals, _ := g.Timeline()
+		rows := []string{}
+		for _, i := range intervals {
+			rows = append(rows, fmt.Sprintf("%s:%d", i.Task.ID(), i.Row))
+		}
+		fmt.Println(rows)
+	}
+	printRows()
+	// y is placed first and takes the top row
+	y.SetPriority(1)
+	printRows()
+	fmt.Print(g)
+	//Output:
+	//[x:0 y:1 z:0]
+	//[x:1 y:0 z:1]
+	//%%{init: {"gantt":{"displayMode":"compact"}}}%%
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section s1
+	//x : x, 2019-06-20, 86400s
+	//y : y, 2019-06-20, 172800s
+	//z : z, 2019-06-21, 86400s
+}
+
+// Using a friday and saturday weekend
+func ExampleGantt_weekendDays() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.ExcludeWeekends = true
+	g.WeekendDays = []time.Weekday{time.Friday, time.Saturday}
+	// five working days starting on a Thursday
+	t1, _ := g.AddTask("t1", "", "120h", "2019-06-20")
+	end, _ := t1.EndDate()
+	fmt.Println(end.Format("Mon 2006-01-02"))
+	fmt.Print(g)
+	// weekends mermaid doesn't know are excluded day by day
+	g.WeekendDays = []time.Weekday{time.Sunday}
+	fmt.Print(g)
+	//Output:
+	//Thu 2019-06-27
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//excludes weekends
+	//weekend friday
+	//t1 : t1, 2019-06-20, 432000s
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//excludes sunday
+	//t1 : t1, 2019-06-20, 432000s
+}
+
+// Checking the dependencies of a plan
+func ExampleGantt_ValidateDependencies() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	design, _ := g.AddTask("design", "", "48h", "2019-06-20")
+	fmt.Println(g.ValidateDependencies())
+	// Start wins over After, but it's before design ends
+	impl, _ := g.AddTask("impl", "", "24h", design)
+	impl.Start = design.Start
+	// a, b and c depend on each other
+	a, _ := g.AddTask("a")
+	b, _ := g.AddTask("b", "", "24h", a)
+	c, _ := g.AddTask("c", "", "24h", b)
+	a.After = c
+	// a Task of another Gantt
+	other, _ := gantt.NewGantt()
+	foreign, _ := other.AddTask("foreign", "", "24h", "2019-06-20T00:00:00Z")
+	g.AddTask("d", "", "24h", foreign)
+	fmt.Println(g.ValidateDependencies())
+	//Output:
+	//<nil>
+	//ValidateDependencies: Task impl starts at 2019-06-20 before Task design ends at 2019-06-22; dependency cycle a -> c -> b -> a; Task d depends on unknown Task foreign
+}
+
+func TestGanttTimelineExcludes(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.AddTask("t1", "", "120h", "2019-06-20")
+	_, end, err := g.Span()
+	assert(t, err == nil && end.Equal(time.Date(2019, 6, 25, 0, 0, 0, 0, time.UTC)),
+		"unexpected end %s without excludes", end)
+	g.ExcludeWeekends = true
+	_, end, err = g.Span()
+	assert(t, err == nil && end.Equal(time.Date(2019, 6, 27, 0, 0, 0, 0, time.UTC)),
+		"unexpected end %s with excludes", end)
+	g.ExcludeDates = []time.Time{time.Date(2019, 6, 24, 0, 0, 0, 0, time.UTC)}
+	_, end, err = g.Span()
+	assert(t, err == nil && end.Equal(time.Date(2019, 6, 28, 0, 0, 0, 0, time.UTC)),
+		"unexpected end %s with holiday", end)
+}
+
+func TestGanttTimelineErrors(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	_, _, err := g.Span()
+	assert(t, err != nil)
+	path, err := g.CriticalPath()
+	assert(t, err == nil && len(path) == 0)
+	t1, _ := g.AddTask("t1")
+	_, err = g.Timeline()
+	assert(t, err != nil && err.Error() ==
+		"Timeline: no start can be resolved for Task t1", "unexpected %v", err)
+	t2, _ := g.AddTask("t2", "", "1h", t1)
+	t1.After = t2
+	_, err = g.CriticalPath()
+	assert(t, err != nil)
+	t2.Critical = true
+	err = g.MarkCriticalPath()
+	assert(t, err != nil && t2.Critical, "unexpected %v", err)
+}
+
+// Adding a Section with all its Tasks at once
+func ExampleGantt_AddSectionWithTasks() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	_, err := g.AddSectionWithTasks("Phase 1", []gantt.TaskSpec{
+		{ID: "spec", Title: "Specification", Start: "2019-07-01", Duration: "72h",
+			Status: gantt.StatusDone},
+		{ID: "impl", Title: "Implementation", Start: "spec", Duration: "120h",
+			Status: gantt.StatusActive},
+		{ID: "bad id", Title: "Review"},
+		{ID: "release", Start: "impl", Status: "shipped"},
+	})
+	fmt.Println(err)
+	fmt.Print(g)
+	//Output:
+	//AddSectionWithTasks: task 2 (bad id): invalid id; task 3 (release): unknown status "shipped"
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section Phase 1
+	//Specification : done, spec, 2019-07-01, 259200s
+	//Implementation : active, impl, after spec, 432000s
+}
+
+// Constraining the syntax to an old mermaid version
+func ExampleGantt_Warnings() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.ExcludeWeekends = true
+	g.WeekendDays = []time.Weekday{time.Friday, time.Saturday}
+	g.Compact = true
+	t1, _ := g.AddTask("t1", "", "24h", "2019-07-01")
+	t2, _ := g.AddTask("t2", "", "72h", "2019-07-01")
+	g.AddGate("gate", "", t1, t2)
+	g.CompatMode = gantt.CompatV8
+	fmt.Print(g)
+	for _, w := range g.Warnings() {
+		fmt.Println(w)
+	}
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//excludes friday, saturday
+	//t1 : t1, 2019-07-01, 86400s
+	//t2 : t2, 2019-07-01, 259200s
+	//gate : milestone, gate, after t2, 0s
+	//weekend friday is rendered as excluded friday and saturday
+	//the Compact display mode is dropped
+	//Task gate is rendered after t2 only, the Dependency ending last
+}
+
+// Rendering a placeholder title instead of a blank diagram
+func ExampleGantt_EmptyPlaceholder() {
+	g, _ := gantt.NewGantt("Release plan")
+	g.EmptyPlaceholder = "(no data)"
+	fmt.Print(g)
+	g.AddTask("t1")
+	fmt.Print(g)
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//title Release plan (no data)
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//title Release plan
+	//t1 : 1d
+}
+
+// Total effort of overlapping Tasks
+func ExampleGantt_TotalEffort() {
+	g, _ := gantt.NewGantt()
+	s1, _ := g.AddSection("backend")
+	t1, _ := s1.AddTask("t1", "", "48h", "2019-06-20T00:00:00Z")
+	s1.AddTask("t2", "", "24h", "2019-06-20T00:00:00Z")
+	s2, _ := g.AddSection("frontend")
+	s2.AddTask("t3", "", "36h", t1)
+	s2.AddMilestone("m1", "launch", "2019-06-24T00:00:00Z")
+	effort, _ := g.TotalEffort()
+	start, end, _ := g.Span()
+	fmt.Println(effort, end.Sub(start))
+	fmt.Println(s1.TotalEffort(), s2.TotalEffort())
+	//Output:
+	//108h0m0s 96h0m0s
+	//72h0m0s 36h0m0s
+}
+
+// A gate reached when all Tasks of a phase are done
+func ExampleGantt_AddGate() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	s, _ := g.AddSection("Phase 1")
+	t1, _ := s.AddTask("backend", "Backend", "120h", "2019-07-01")
+	t2, _ := s.AddTask("frontend", "Frontend", "72h", "2019-07-02")
+	gate, _ := s.AddGate("review", "Phase review", t1, t2)
+	end, _ := gate.EndDate()
+	path, _ := g.CriticalPath()
+	fmt.Println(end.Format("2006-01-02"), path[0].ID(), path[1].ID())
+	_, err := g.AddGate("release", "Release")
+	fmt.Println(err)
+	fmt.Print(g)
+	//Output:
+	//2019-07-06 backend review
+	//AddGate: no Tasks given
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section Phase 1
+	//Backend : backend, 2019-07-01, 432000s
+	//Frontend : frontend, 2019-07-02, 259200s
+	//Phase review : milestone, review, after backend frontend, 0s
+}
+
+// Shrinking the bars of a dense diagram
+func ExampleGantt_BarHeight() {
+	g, _ := gantt.NewGantt()
+	g.BarHeight = 12
+	g.FontSize = 9
+	g.AddTask("t1")
+	fmt.Print(g)
+	g.SectionFontSize = -1
+	_, err := g.StringSafe()
+	fmt.Println(err)
+	//Output:
+	//%%{init: {"gantt":{"barHeight":12,"fontSize":9}}}%%
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//t1 : 1d
+	//StringSafe: BarHeight 12, FontSize 9 and SectionFontSize -1 must not be negative
+}
+
+// Linking Tasks to a tracker
+func ExampleGantt_AddClick() {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("s1")
+	g.AddTask("t1", "top level", "24h", "2019-06-20T00:00:00Z")
+	s.AddTask("t2", "in a section")
+	g.AddClick("t2", "https://example.com/t2")
+	g.AddClick("t1", "https://example.com/t1")
+	fmt.Print(g)
+	// unknown Task IDs are reported by StringSafe
+	g.AddClick("t3", "https://example.com/t3")
+	_, err := g.StringSafe()
+	fmt.Println(err)
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//top level : t1, 2019-06-20T00:00:00Z, 86400s
+	//section s1
+	//in a section : t2, after t1, 1d
+	//click t1 href "https://example.com/t1"
+	//click t2 href "https://example.com/t2"
+	//StringSafe: click references unknown Task t3
+}
+
+func TestGantt_StringSafeClickOnFirstTask(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	g.AddTask("t1", "first")
+	g.AddTask("t2", "second")
+	g.AddClick("t2", "https://example.com/t2")
+	_, err := g.StringSafe()
+	assert(t, err == nil, "click on second Task: %v", err)
+	g.AddClick("t1", "https://example.com/t1")
+	_, err = g.StringSafe()
+	assert(t, err != nil && strings.Contains(err.Error(), "Task t1"),
+		"click on first Task without Start or After: %v", err)
+	g.GetTask("t1").Start = &time.Time{}
+	_, err = g.StringSafe()
+	assert(t, err == nil, "click on first Task with Start: %v", err)
+}
+
 func TestGanttAddDuplicateTask(t *testing.T) {
 	g, _ := gantt.NewGantt()
 	t1, _ := g.AddTask("t1")
@@ -160,3 +627,138 @@ func TestGanttAddDuplicateSection(t *testing.T) {
 	assert(t, s2 == nil)
 	assert(t, err != nil)
 }
+
+// Drawing the dependencies of the Tasks as a companion Flowchart
+func ExampleGantt_DependencyFlowchart() {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("build")
+	design, _ := s.AddTask("design", "Design", "72h")
+	impl, _ := s.AddTask("impl", "Implement", "120h", design)
+	docs, _ := s.AddTask("docs", "Write docs", "48h", design)
+	g.AddGate("release", "Release", impl, docs)
+	fmt.Print(g.DependencyFlowchart())
+	//Output:
+	//graph LR
+	//
+	//   release{"Release"}
+	//   design["Design"]
+	//   impl["Implement"]
+	//   docs["Write docs"]
+	//
+	//   impl --> release
+	//   docs --> release
+	//   design --> impl
+	//   design --> docs
+}
+
+func BenchmarkGantt_LiveURL(b *testing.B) {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("s")
+	prev, _ := s.AddTask("t0", "Task 0", "24h")
+	for i := 1; i < 50; i++ {
+		prev, _ = s.AddTask(fmt.Sprintf("t%d", i), fmt.Sprintf("Task %d", i),
+			"24h", prev)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g.LiveURL()
+	}
+}
+
+// Moving the whole plan when the project start slips by a week
+func ExampleGantt_Shift() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	kickoff, _ := g.AddTask("kickoff", "Kickoff", "24h", "2021-01-04")
+	g.AddTask("work", "Work", "72h", kickoff)
+	g.AddMarker("Review", "2021-01-08")
+	if err := g.Shift(7 * 24 * time.Hour); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(g.Shift(36 * time.Hour))
+	fmt.Print(g)
+	//Output:
+	//Shift: start 2021-01-12T12:00:00Z of Task kickoff can't be written as DateFormat "YYYY-MM-DD"
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//Kickoff : kickoff, 2021-01-11, 86400s
+	//Work : work, after kickoff, 259200s
+	//section Markers
+	//Review : milestone, _marker1, 2021-01-15, 0s
+}
+
+// Showing a future phase without Tasks as a labeled divider
+func ExampleGantt_KeepEmptySections() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	g.KeepEmptySections = true
+	later, _ := g.AddSection("Later")
+	now, _ := g.AddSection("Now")
+	now.AddTask("t1", "Build", "48h", "2021-01-04")
+	later.SetBandColor("#eee")
+	g.AddSection("Someday")
+	fmt.Print(g)
+	start, end, _ := g.Span()
+	fmt.Println(start.Format("2006-01-02"), end.Format("2006-01-02"))
+	//Output:
+	//%%{init: {"gantt":{"numberSectionStyles":3},"themeCSS":".section0 { fill: #eee; }"}}%%
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section Later
+	//​ : 2021-01-04, 0s
+	//section Now
+	//Build : t1, 2021-01-04, 172800s
+	//section Someday
+	//​ : 0s
+	//2021-01-04 2021-01-06
+}
+
+// Coloring the critical Tasks of the Timeline
+func ExampleGantt_CriticalSet() {
+	g, _ := gantt.NewGantt()
+	a, _ := g.AddTask("a", "Design", "48h", "2021-01-04T00:00:00Z")
+	g.AddTask("b", "Docs", "24h", a)
+	g.AddTask("c", "Build", "72h", a)
+	critical, _ := g.CriticalSet()
+	intervals, _ := g.Timeline()
+	for _, i := range intervals {
+		fmt.Println(i.Task.ID(), critical[i.Task])
+	}
+	a.SetStart(g.GetTask("c")) // a cycle
+	_, err := g.CriticalSet()
+	fmt.Println(err != nil)
+	//Output:
+	//a true
+	//b false
+	//c true
+	//true
+}
+
+func TestGantt_ShiftLocation(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	task, _ := g.AddTask("t", "Task", "24h",
+		time.Date(2021, 1, 4, 0, 0, 0, 0, paris))
+	if err := g.Shift(7 * 24 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2021, 1, 11, 0, 0, 0, 0, paris)
+	assert(t, task.Start.Equal(want), "expected the start %s, got %s", want,
+		task.Start)
+	assert(t, g.Shift(36*time.Hour) != nil, "expected an error for 36h")
+}
+
+func TestGantt_DependencyFlowchartForeignTask(t *testing.T) {
+	other, _ := gantt.NewGantt()
+	foreign, _ := other.AddTask("a", "", "24h", "2021-01-04T00:00:00Z")
+	g, _ := gantt.NewGantt()
+	g.AddTask("a", "", "24h", "2021-01-04T00:00:00Z")
+	g.AddTask("b", "", "24h", foreign)
+	fc := g.DependencyFlowchart()
+	assert(t, len(fc.ListEdges()) == 0,
+		"expected no Edge to the local Task a, got\n%s", fc)
+}
@@ -0,0 +1,114 @@
+package gantt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseOutline builds a Gantt diagram from a plain text outline, a lightweight
+// format for drafting plans by hand. Lines starting without indentation are
+// Section titles, indented lines (spaces or tabs) are Tasks of the Section
+// above in the form "Title, start, duration":
+//
+//	Design
+//	    Research, 2021-01-04, 3d
+//	    Mockups, , 2d
+//	Build
+//	    Implement, 2021-01-11, 1w
+//
+// The start is a date in DateFormatDate (YYYY-MM-DD), which the returned Gantt
+// uses as DateFormat. It may be left empty to let mermaid start the Task after
+// the previous one. The duration is a number of days ("3d"), weeks ("1w") or a
+// Go duration like "36h". Tasks are assigned the IDs t1, t2 and so on in the
+// order of the outline. Indented lines before the first Section add Tasks
+// without Section. Blank lines are skipped. Malformed rows fail with an error
+// naming the line.
+func ParseOutline(r io.Reader) (g *Gantt, err error) {
+	g, _ = NewGantt()
+	g.DateFormat = DateFormatDate
+	var section *Section
+	scanner := bufio.NewScanner(r)
+	tasks := 0
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			section, err = g.AddSection(strings.TrimSpace(line))
+			if err != nil {
+				return nil, fmt.Errorf("ParseOutline: line %d: Section %q: %s",
+					lineNo, strings.TrimSpace(line), err)
+			}
+			continue
+		}
+		tasks++
+		if err := outlineTask(g, section, fmt.Sprintf("t%d", tasks),
+			line); err != nil {
+			return nil, fmt.Errorf("ParseOutline: line %d: %s", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ParseOutline: %s", err)
+	}
+	return g, nil
+}
+
+// Helperfunction to add the Task of an indented outline line.
+func outlineTask(g *Gantt, s *Section, id, line string) error {
+	fields := strings.Split(line, ",")
+	if len(fields) != 3 {
+		return fmt.Errorf(`expected "Title, start, duration", got %d fields`,
+			len(fields))
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if fields[0] == "" {
+		return fmt.Errorf("missing title")
+	}
+	duration, err := parseOutlineDuration(fields[2])
+	if err != nil {
+		return err
+	}
+	init := []interface{}{fields[0], duration}
+	if fields[1] != "" {
+		start, err := g.parseDate(fields[1])
+		if err != nil {
+			return fmt.Errorf(`start "%s" is no %s date`, fields[1],
+				g.dateFormatName())
+		}
+		init = append(init, start)
+	}
+	if s != nil {
+		_, err = s.AddTask(id, init...)
+	} else {
+		_, err = g.AddTask(id, init...)
+	}
+	return err
+}
+
+// Helperfunction to parse days ("3d"), weeks ("1w") or Go durations.
+func parseOutlineDuration(duration string) (time.Duration, error) {
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if n := len(duration); n > 1 {
+		if unit, ok := units[duration[n-1]]; ok {
+			if count, err := strconv.Atoi(duration[:n-1]); err == nil && count > 0 {
+				return time.Duration(count) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf(`duration "%s" is neither days, weeks nor a`+
+			` positive Go duration`, duration)
+	}
+	return d, nil
+}
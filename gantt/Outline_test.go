@@ -0,0 +1,57 @@
+package gantt_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/StephenBrown2/mermaidgen/gantt"
+)
+
+// Building a Gantt diagram from a plan drafted as an outline
+func ExampleParseOutline() {
+	outline := `Design
+    Research, 2021-01-04, 3d
+    Mockups, , 2d
+
+Build
+	Implement, 2021-01-11, 1w
+`
+	g, err := gantt.ParseOutline(strings.NewReader(outline))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(g)
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DD
+	//section Design
+	//Research : t1, 2021-01-04, 259200s
+	//Mockups : 172800s
+	//section Build
+	//Implement : t3, 2021-01-11, 604800s
+}
+
+func TestParseOutline(t *testing.T) {
+	for _, c := range []struct{ outline, err string }{
+		{"S\n  a, 2021-01-04\n", "line 2: expected"},
+		{"S\n\n  , 2021-01-04, 1d\n", "line 3: missing title"},
+		{"S\n  a, 04.01.2021, 1d\n", `line 2: start "04.01.2021"`},
+		{"S\n  a, , 0d\n", `line 2: duration "0d"`},
+		{"S\n  a, , 1d\nS\n", `line 3: Section "S"`},
+	} {
+		_, err := gantt.ParseOutline(strings.NewReader(c.outline))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expected error containing %q for %q, got %v",
+				c.err, c.outline, err)
+		}
+	}
+	g, err := gantt.ParseOutline(strings.NewReader("  loose, , 36h\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tasks := g.ListLocalTasks(); len(tasks) != 1 || tasks[0].Title != "loose" {
+		t.Errorf("expected a Task without Section, got %v", tasks)
+	}
+}
@@ -2,16 +2,24 @@ package gantt
 
 import (
 	"fmt"
+	"math"
+	"time"
 )
 
 // Section represents gantt sections that can be added to the Gantt diagram.
 // Create an instance of Section via Gantt's AddSection method, do not create
 // instances directly. Already defined IDs can be looked up via Gantt's
 // GetSection method or iterated over via its ListSections method.
+// DateHint optionally documents the granularity the Section was planned at
+// (e.g. "weekly" for a roughly planned future phase). Mermaid has only one
+// date axis, so the hint doesn't change the rendered diagram, it is only
+// exported for downstream consumers, see Task's MarshalJSON.
 type Section struct {
-	id    string
-	gantt *Gantt
-	tasks []*Task
+	id        string
+	gantt     *Gantt
+	tasks     []*Task
+	bandColor string
+	DateHint  string // Optional planning granularity, not rendered.
 }
 
 // Private constructor for use in Add-functions.
@@ -37,21 +45,70 @@ func (s *Section) Gantt() (topLevel *Gantt) {
 	return s.gantt
 }
 
+// SetBandColor sets a CSS color (e.g. "#fdd") used to tint this Section's
+// background band. It is rendered as themeCSS via an init directive, which
+// only affects the band, not the Tasks' status colors. Renderers without
+// themeCSS support ignore it. An empty string removes the band color. Beyond
+// its default of 4, mermaid's themes don't color the bands, so the colors of
+// the fifth and later Sections are only used if all of them have one,
+// otherwise their bands repeat the first four.
+func (s *Section) SetBandColor(css string) {
+	s.bandColor = css
+}
+
+// BandColor returns the CSS color set via SetBandColor.
+func (s *Section) BandColor() (css string) {
+	return s.bandColor
+}
+
 // String renders this diagram element to a section definition line.
 func (s *Section) String() (renderedElement string) {
-	renderedElement = fmt.Sprintln("section", s.id)
+	renderedElement, _ = s.render(nil, s.gantt.tasksNeedingID())
+	return
+}
+
+// render is the implementation of String, prev is the Task rendered before
+// this Section and the last Task rendered is returned. See Task's render.
+func (s *Section) render(prev *Task, needID map[*Task]bool) (string, *Task) {
+	title := s.id
+	if s.gantt.AnnotateSectionProgress && len(s.tasks) > 0 {
+		title += fmt.Sprintf(" (%d%%)", int(math.Round(s.Progress()*100)))
+	}
+	text := fmt.Sprintln("section", title)
 	for _, task := range s.tasks {
-		renderedElement += task.String()
+		text += task.render(prev, needID[task])
+		prev = task
 	}
-	return
+	if len(s.tasks) == 0 && s.gantt.KeepEmptySections {
+		text += s.gantt.emptySectionPlaceholder(prev)
+	}
+	return text, prev
+}
+
+// Title of the placeholder Task of empty Sections, a zero width space since
+// mermaid needs a title but trims whitespace.
+const emptySectionTitle = "\u200b"
+
+// emptySectionPlaceholder renders the invisible Task that makes mermaid show an
+// empty Section, see KeepEmptySections. It takes no time right after prev,
+// mermaid's default for Tasks without start, or at the start of the diagram
+// if there is no previous Task, since mermaid would start it today instead.
+func (g *Gantt) emptySectionPlaceholder(prev *Task) string {
+	if prev == nil {
+		if start, _, err := g.Span(); err == nil {
+			return fmt.Sprintf("%s : %s, 0s\n", emptySectionTitle,
+				g.formatDate(start))
+		}
+	}
+	return emptySectionTitle + " : 0s\n"
 }
 
 // AddTask is used to add a new Task to this Section. If the provided ID already
 // exists or is invalid, no new Task is created and an error is returned.
 // The ID can later be used to look up the created Task using Gantt's GetTask
 // method. Optional initializer parameters can be given in the order Title,
-// Duration, Start, Critical, Active, Done. Duration and Start are set via
-// Task's SetDuration and SetStart respectively.
+// Duration, Start, Critical, Active, Done, Milestone. Duration and Start are
+// set via Task's SetDuration and SetStart respectively.
 func (s *Section) AddTask(id string, init ...interface{}) (newTask *Task, err error) {
 	newTask, err = taskNew(id, s.gantt, s, init)
 	if err != nil {
@@ -62,6 +119,144 @@ func (s *Section) AddTask(id string, init ...interface{}) (newTask *Task, err er
 	return
 }
 
+// addTaskSpec adds the Task described by spec, nothing is added on error.
+func (s *Section) addTaskSpec(spec TaskSpec) error {
+	t, err := taskNew(spec.ID, s.gantt, s, []interface{}{spec.Title})
+	if err != nil {
+		return err
+	}
+	if spec.Start != nil {
+		if err := t.SetStart(spec.Start); err != nil {
+			return err
+		}
+	}
+	if spec.Duration != nil {
+		if err := t.SetDuration(spec.Duration); err != nil {
+			return err
+		}
+	}
+	switch spec.Status {
+	case "", StatusPlanned:
+	case StatusCritical:
+		t.Critical = true
+	case StatusActive:
+		t.Active = true
+	case StatusDone:
+		t.Done = true
+	case StatusMilestone:
+		t.Milestone = true
+	default:
+		return fmt.Errorf("unknown status %q", string(spec.Status))
+	}
+	s.gantt.tasksMap[spec.ID] = t
+	s.tasks = append(s.tasks, t)
+	return nil
+}
+
+// AddMilestone is used to add a new milestone Task to this Section. The Task
+// starts at the given date (see Gantt's DateFormat), has a Duration of 0 and
+// the Milestone flag set. If the provided ID already exists or is invalid or
+// the date can't be parsed, no new Task is created and an error is returned.
+func (s *Section) AddMilestone(id, title, date string) (newTask *Task, err error) {
+	start, err := s.gantt.parseMilestoneDate(date)
+	if err != nil {
+		return nil, err
+	}
+	newTask, err = s.AddTask(id, title, time.Duration(0), start)
+	if err != nil {
+		return nil, err
+	}
+	newTask.Milestone = true
+	return
+}
+
+// AddGate is used to add a new milestone Task to this Section that is reached
+// when all given Tasks have ended, see Gantt's AddGate.
+func (s *Section) AddGate(id, title string, after ...*Task) (newTask *Task, err error) {
+	newTask, err = gateNew(id, s.gantt, s, title, after)
+	if err != nil {
+		return nil, err
+	}
+	s.gantt.tasksMap[id] = newTask
+	s.tasks = append(s.tasks, newTask)
+	return newTask, nil
+}
+
+// AddRecurring is used to add count Tasks with the IDs baseID-1 to
+// baseID-<count> to this Section, e.g. for sprints or recurring meetings. All
+// Tasks get the given title and duration (see Task's SetDuration), the first
+// one starts at start (a date according to Gantt's DateFormat) and each
+// following one is offset by every. If any ID already exists or is invalid or
+// the parameters are invalid, no Task is created and an error is returned.
+func (s *Section) AddRecurring(baseID, title string, start string,
+	every time.Duration, count int, dur string) (newTasks []*Task, err error) {
+	if count < 1 {
+		return nil, fmt.Errorf("AddRecurring: count must be positive")
+	}
+	if every <= 0 {
+		return nil, fmt.Errorf("AddRecurring: every must be positive")
+	}
+	first, err := s.gantt.parseDate(start)
+	if err != nil {
+		return nil, fmt.Errorf(`AddRecurring: "%s" is no %s date`, start,
+			s.gantt.dateFormatName())
+	}
+	if _, err := time.ParseDuration(dur); err != nil && s.gantt.GetTask(dur) == nil {
+		return nil, fmt.Errorf(
+			`AddRecurring: "%s" is neither a valid duration nor Task ID`, dur)
+	}
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", baseID, i+1)
+		if !IsValidID(ids[i]) {
+			return nil, fmt.Errorf("AddRecurring: invalid id %s", ids[i])
+		}
+		if s.gantt.GetTask(ids[i]) != nil {
+			return nil, fmt.Errorf("AddRecurring: id %s already exists", ids[i])
+		}
+	}
+	for i, id := range ids {
+		task, err := s.AddTask(id, title, dur, first.Add(every*time.Duration(i)))
+		if err != nil {
+			return newTasks, err
+		}
+		newTasks = append(newTasks, task)
+	}
+	return newTasks, nil
+}
+
+// TotalEffort returns the sum of the Durations of this Section's Tasks, see
+// Gantt's TotalEffort.
+func (s *Section) TotalEffort() (effort time.Duration) {
+	return sumEffort(s.tasks)
+}
+
+// Progress returns the completed fraction of this Section's Tasks between 0
+// and 1: the Durations of the done Tasks divided by the Durations of all Tasks
+// (see TotalEffort). If the Tasks have no Duration, like milestones, the share
+// of done Tasks is returned instead, 0 without Tasks.
+func (s *Section) Progress() (done float64) {
+	if total := s.TotalEffort(); total > 0 {
+		doneTasks := []*Task{}
+		for _, t := range s.tasks {
+			if t.Done {
+				doneTasks = append(doneTasks, t)
+			}
+		}
+		return float64(sumEffort(doneTasks)) / float64(total)
+	}
+	if len(s.tasks) == 0 {
+		return 0
+	}
+	count := 0
+	for _, t := range s.tasks {
+		if t.Done {
+			count++
+		}
+	}
+	return float64(count) / float64(len(s.tasks))
+}
+
 // ListLocalTasks returns a slice of all Tasks previously added to this
 // Section in the order they were defined.
 func (s *Section) ListLocalTasks() (localTasks []*Task) {
@@ -1,10 +1,13 @@
 package gantt_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/Heiko-san/mermaidgen/gantt"
+	"github.com/StephenBrown2/mermaidgen/gantt"
 )
 
 // Iterate over the Tasks of a Section
@@ -40,6 +43,142 @@ func ExampleSection_privateFields() {
 	//this_is_my_id true
 }
 
+// Adding milestones to a Section
+func ExampleSection_AddMilestone() {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("s1")
+	s.AddTask("t1", "a task", "24h", "2019-06-20T00:00:00Z")
+	s.AddMilestone("m1", "release", "2019-06-21T00:00:00Z")
+	// errors are returned for duplicate IDs and invalid dates
+	_, err := s.AddMilestone("m1", "again", "2019-06-21T00:00:00Z")
+	fmt.Println(err)
+	_, err = g.AddMilestone("m2", "broken", "tomorrow")
+	fmt.Println(err)
+	fmt.Print(s)
+	//Output:
+	//id already exists
+	//AddMilestone: "tomorrow" is no RFC3339 date
+	//section s1
+	//a task : t1, 2019-06-20T00:00:00Z, 86400s
+	//release : milestone, m1, 2019-06-21T00:00:00Z, 0s
+}
+
+// Tinting the background band of Sections
+func ExampleSection_SetBandColor() {
+	g, _ := gantt.NewGantt()
+	g.AddTask("t0")
+	s1, _ := g.AddSection("s1")
+	s1.AddTask("t1")
+	s2, _ := g.AddSection("s2")
+	s2.AddTask("t2", "", "1h", "2019-06-20T00:00:00Z", true)
+	s2.SetBandColor("#fdd")
+	fmt.Print(g)
+	//Output:
+	//%%{init: {"gantt":{"numberSectionStyles":3},"themeCSS":".section2 { fill: #fdd; }"}}%%
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//t0 : 1d
+	//section s1
+	//t1 : 1d
+	//section s2
+	//t2 : crit, t2, 2019-06-20T00:00:00Z, 3600s
+}
+
+// Tinting the bands of more than four Sections
+func ExampleSection_manyBandColors() {
+	g, _ := gantt.NewGantt()
+	for i, color := range []string{"", "#fdd", "", "", "#dfd", "#ddf"} {
+		s, _ := g.AddSection(fmt.Sprint("s", i))
+		s.AddTask(fmt.Sprint("t", i))
+		s.SetBandColor(color)
+	}
+	fmt.Println(strings.SplitN(g.String(), "\n", 2)[0])
+	// the sixth band would have no fill, so mermaid's default 4 bands repeat
+	// and only the colors of the first four Sections are kept
+	g.GetSection("s5").SetBandColor("")
+	fmt.Println(strings.SplitN(g.String(), "\n", 2)[0])
+	//Output:
+	//%%{init: {"gantt":{"numberSectionStyles":6},"themeCSS":".section1 { fill: #fdd; } .section4 { fill: #dfd; } .section5 { fill: #ddf; }"}}%%
+	//%%{init: {"gantt":{"numberSectionStyles":4},"themeCSS":".section1 { fill: #fdd; }"}}%%
+}
+
+// Adding recurring Tasks like sprints
+func ExampleSection_AddRecurring() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	s, _ := g.AddSection("Sprints")
+	week := 7 * 24 * time.Hour
+	s.AddRecurring("sprint", "Sprint", "2019-07-01", 2*week, 3, "240h")
+	// none of the Tasks is created if one of them would fail
+	_, err := s.AddRecurring("sprint", "Sprint", "2019-08-12", 2*week, 5, "240h")
+	fmt.Println(err, len(s.ListLocalTasks()))
+	fmt.Print(s)
+	//Output:
+	//AddRecurring: id sprint-1 already exists 3
+	//section Sprints
+	//Sprint : sprint-1, 2019-07-01, 864000s
+	//Sprint : sprint-2, 2019-07-15, 864000s
+	//Sprint : sprint-3, 2019-07-29, 864000s
+}
+
+// Showing the completion of each Section in its title
+func ExampleSection_Progress() {
+	g, _ := gantt.NewGantt()
+	g.AnnotateSectionProgress = true
+	s1, _ := g.AddSection("Phase 1")
+	s1.AddTask("t1", "", "72h", "2019-06-20T00:00:00Z", false, false, true)
+	s1.AddTask("t2", "", "48h")
+	s2, _ := g.AddSection("Phase 2")
+	s2.AddMilestone("m1", "", "2019-07-01T00:00:00Z")
+	fmt.Println(s1.Progress(), s2.Progress())
+	fmt.Print(g)
+	//Output:
+	//0.6 0
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//section Phase 1 (60%)
+	//t1 : done, t1, 2019-06-20T00:00:00Z, 259200s
+	//t2 : 172800s
+	//section Phase 2 (0%)
+	//m1 : milestone, m1, 2019-07-01T00:00:00Z, 0s
+}
+
+// Documenting the planning granularity of a Section for exports
+func ExampleSection_dateHint() {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("later")
+	s.DateHint = "weekly"
+	t, _ := s.AddTask("t1", "research", "168h")
+	data, _ := json.Marshal(t)
+	fmt.Println(string(data))
+	fmt.Print(s)
+	//Output:
+	//{"id":"t1","title":"research","section":"later","dateHint":"weekly","duration":"168h0m0s","status":"planned"}
+	//section later
+	//research : 604800s
+}
+
+func TestSection_addRecurringErrors(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("s")
+	start := "2019-07-01T00:00:00Z"
+	_, err := s.AddRecurring("r", "", start, time.Hour, 0, "1h")
+	assert(t, err != nil)
+	_, err = s.AddRecurring("r", "", start, 0, 2, "1h")
+	assert(t, err != nil)
+	_, err = s.AddRecurring("r", "", "July", time.Hour, 2, "1h")
+	assert(t, err != nil)
+	_, err = s.AddRecurring("r", "", start, time.Hour, 2, "soon")
+	assert(t, err != nil)
+	_, err = s.AddRecurring("r r", "", start, time.Hour, 2, "1h")
+	assert(t, err != nil)
+	assert(t, len(g.ListTasks()) == 0)
+	tasks, err := s.AddRecurring("r", "", start, time.Hour, 2, "1h")
+	assert(t, err == nil)
+	assert(t, len(tasks) == 2 && tasks[1].ID() == "r-2")
+	assert(t, tasks[1].Start.Sub(*tasks[0].Start) == time.Hour)
+}
+
 func TestSection_addDuplicateTask(t *testing.T) {
 	g, _ := gantt.NewGantt()
 	s, _ := g.AddSection("s")
@@ -1,6 +1,7 @@
 package gantt
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"regexp"
@@ -13,21 +14,49 @@ import (
 // the same time.
 var IsValidID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString
 
+// Status is the canonical status of a Task, derived from its flags. Exports
+// use it instead of the separate flags, see Task's Status method.
+type Status string
+
+// Status definitions, see Task's Status method for how they are derived.
+const (
+	StatusPlanned   Status = "planned"
+	StatusCritical  Status = "critical"
+	StatusActive    Status = "active"
+	StatusDone      Status = "done"
+	StatusMilestone Status = "milestone"
+)
+
 // Task represents gantt tasks that can be added to Sections or the Gantt
 // diagram itself. Create an instance of Task via Gantt's or Section's AddTask
 // method, do not create instances directly. Already defined IDs can be looked
 // up via Gantt's GetTask method or iterated over via its ListTasks method.
 type Task struct {
-	id       string         // Task ID
-	gantt    *Gantt         // The top level Gantt diagram
-	section  *Section       // The Section this Task belongs to
-	Title    string         // Title of the Task, if not set, ID is used
-	Start    *time.Time     // Time when the Task starts (Start wins over After)
-	After    *Task          // Task after which this Task starts
-	Duration *time.Duration // Duration of the Task (the absolute value is used)
-	Critical bool           // The crit flag
-	Active   bool           // The active flag
-	Done     bool           // The done flag
+	id        string         // Task ID
+	gantt     *Gantt         // The top level Gantt diagram
+	section   *Section       // The Section this Task belongs to
+	Title     string         // Title of the Task, if not set, ID is used
+	Start     *time.Time     // Time when the Task starts (Start wins over After)
+	After     *Task          // Task after which this Task starts
+	afterMore []*Task        // Further dependencies, see SetAfter
+	Duration  *time.Duration // Duration of the Task (the absolute value is used)
+	Critical  bool           // The crit flag
+	Active    bool           // The active flag
+	Done      bool           // The done flag
+	Milestone bool           // The milestone flag
+	classes   []string       // Custom classes, see AddClass
+	priority  int            // Placement priority, see SetPriority
+}
+
+// tags with a special meaning to mermaid that can't be used as classes
+var reservedTags = map[string]bool{
+	"crit": true, "active": true, "done": true, "milestone": true,
+	"after": true, "until": true,
+}
+
+// isValidClass checks if name can be used as a custom Task class.
+func isValidClass(name string) bool {
+	return IsValidID(name) && !reservedTags[name]
 }
 
 // Private constructor for use in Add-functions.
@@ -41,6 +70,12 @@ func taskNew(i string, g *Gantt, s *Section, p []interface{}) (*Task, error) {
 	}
 	t := &Task{id: i, gantt: g, section: s}
 	switch l, ok := len(p), false; {
+	case l > 6:
+		t.Milestone, ok = p[6].(bool)
+		if !ok {
+			return nil, fmt.Errorf("value for Milestone was no bool")
+		}
+		fallthrough
 	case l > 5:
 		t.Done, ok = p[5].(bool)
 		if !ok {
@@ -86,9 +121,13 @@ func (t *Task) CopyFields(task *Task) {
 		t.Critical = task.Critical
 		t.Active = task.Active
 		t.Done = task.Done
+		t.Milestone = task.Milestone
+		t.classes = append([]string(nil), task.classes...)
+		t.priority = task.priority
 		t.Title = task.Title
 		// After should be copied as pointer to the same object
 		t.After = task.After
+		t.afterMore = append([]*Task(nil), task.afterMore...)
 		t.SetDuration(task)
 		if task.Start == nil {
 			t.Start = nil
@@ -117,8 +156,104 @@ func (t *Task) Section() (containingSection *Section) {
 	return t.section
 }
 
+// AddClass tags this Task with a custom class that is rendered behind the
+// status flags, e.g. to mark the team responsible for it. Multiple classes are
+// allowed, adding a class twice has no effect. Use Gantt's SetClassDef to
+// style the classes. Since mermaid only accepts classes in front of a Task's
+// ID, Tasks with classes always get their ID rendered; a Task without Start
+// and After that comes first in the diagram has no ID position, so its classes
+// are omitted. An error is returned for invalid class names and mermaid's
+// reserved tags like crit and done.
+func (t *Task) AddClass(name string) (err error) {
+	if !isValidClass(name) {
+		return fmt.Errorf("AddClass: invalid class name %q", name)
+	}
+	for _, class := range t.classes {
+		if class == name {
+			return nil
+		}
+	}
+	t.classes = append(t.classes, name)
+	return nil
+}
+
+// Classes returns a copy of the custom classes added via AddClass.
+func (t *Task) Classes() (classes []string) {
+	return append([]string(nil), t.classes...)
+}
+
+// SetPriority sets the priority used to place this Task when the Gantt's
+// Compact mode packs Tasks into rows. Tasks with a higher priority are placed
+// first, so they end up in the top rows of their Section. The default is 0.
+// See Gantt's Timeline.
+func (t *Task) SetPriority(priority int) {
+	t.priority = priority
+}
+
+// Priority returns the priority set via SetPriority.
+func (t *Task) Priority() (priority int) {
+	return t.priority
+}
+
+// Status derives the canonical Status from this Task's flags. If multiple
+// flags are set, the first one of Milestone, Active, Done and Critical wins
+// (active > done like mermaid renders it), without any flag the Task is
+// StatusPlanned.
+func (t *Task) Status() (status Status) {
+	switch {
+	case t.Milestone:
+		return StatusMilestone
+	case t.Active:
+		return StatusActive
+	case t.Done:
+		return StatusDone
+	case t.Critical:
+		return StatusCritical
+	}
+	return StatusPlanned
+}
+
+// Struct for JSON encode
+type taskJSON struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title,omitempty"`
+	Section  string   `json:"section,omitempty"`
+	DateHint string   `json:"dateHint,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	After    string   `json:"after,omitempty"`
+	Duration string   `json:"duration"`
+	Status   Status   `json:"status"`
+	Classes  []string `json:"classes,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The Task is encoded with its ID,
+// Title, Section ID and DateHint, Start (RFC3339) or the space separated IDs
+// of its Dependencies, Duration (e.g. "24h0m0s", see String for the default),
+// classes and Status instead of the flags.
+func (t *Task) MarshalJSON() (data []byte, err error) {
+	x := taskJSON{ID: t.id, Title: t.Title, Duration: t.duration().String(),
+		Status: t.Status(), Classes: t.classes}
+	if t.section != nil {
+		x.Section = t.section.id
+		x.DateHint = t.section.DateHint
+	}
+	if t.Start != nil {
+		x.Start = t.Start.Format(time.RFC3339)
+	} else if t.After != nil {
+		x.After = t.dependencyIDs()
+	}
+	return json.Marshal(x)
+}
+
 // String renders this diagram element to a task definition line.
 func (t *Task) String() (renderedElement string) {
+	return t.render(nil, false)
+}
+
+// render is the implementation of String. If needID is set and this Task has
+// neither Start nor After, it is rendered to start after prev (which is what
+// mermaid does implicitly) so its ID can be rendered, too.
+func (t *Task) render(prev *Task, needID bool) string {
 	title := t.Title
 	if title == "" {
 		title = t.id
@@ -135,32 +270,57 @@ func (t *Task) String() (renderedElement string) {
 	if t.Done {
 		tokens = append(tokens, "done")
 	}
-	// functional
+	if t.Milestone {
+		tokens = append(tokens, "milestone")
+	}
+	// functional, classes are only valid in front of the id and id without
+	// start statement breaks syntax
 	if t.Start != nil {
-		// id without start statement breaks syntax
-		tokens = append(tokens, t.id, t.Start.Format(time.RFC3339))
+		tokens = append(tokens, t.classes...)
+		tokens = append(tokens, t.id, t.gantt.formatDate(*t.Start))
 	} else if t.After != nil {
-		tokens = append(tokens, t.id, "after "+t.After.id)
+		tokens = append(tokens, t.classes...)
+		after := t.dependencyIDs()
+		if !t.gantt.supports(10) {
+			// mermaid 8 only knows a single Task to start after
+			after = t.latestDependency(make(resolvedDates)).id
+		}
+		tokens = append(tokens, t.id, "after "+after)
+	} else if needID && prev != nil {
+		tokens = append(tokens, t.classes...)
+		tokens = append(tokens, t.id, "after "+prev.id)
 	}
 	duration := "1d"
 	if t.Duration != nil {
 		duration = fmt.Sprintf("%ds", int(math.Abs(t.Duration.Seconds())))
 	}
 	tokens = append(tokens, duration)
-	renderedElement = fmt.Sprintf("%s : %s\n", title, strings.Join(tokens, ", "))
-	return
+	return fmt.Sprintf("%s : %s\n", title, strings.Join(tokens, ", "))
+}
+
+// Helperfunction to validate the date of a milestone.
+func (g *Gantt) parseMilestoneDate(date string) (*time.Time, error) {
+	x, err := g.parseDate(date)
+	if err != nil {
+		return nil, fmt.Errorf(`AddMilestone: "%s" is no %s date`, date,
+			g.dateFormatName())
+	}
+	return &x, nil
 }
 
 // SetStart takes a time.Time or a pointer to it, a Task pointer or a string
-// that represents an existing Task ID or a RFC3339 time definition and sets
-// this Task's Start or After field from that information. An error is returned
-// if the given type is not supported or the string can't be parsed.
+// that represents an existing Task ID or a time definition according to the
+// Gantt's DateFormat (RFC3339 by default) and sets this Task's Start or After
+// field from that information, a Task replaces all Dependencies set via
+// SetAfter. An error is returned if the given type is not supported or the
+// string can't be parsed.
 func (t *Task) SetStart(start interface{}) (err error) {
 	switch tStart := start.(type) {
 	case *time.Time:
 		t.Start = tStart
 	case *Task:
 		t.After = tStart
+		t.afterMore = nil
 		// time > after -> unset time
 		t.Start = nil
 	case time.Time:
@@ -168,13 +328,14 @@ func (t *Task) SetStart(start interface{}) (err error) {
 	case string:
 		if task := t.gantt.GetTask(tStart); task != nil {
 			t.After = task
+			t.afterMore = nil
 			t.Start = nil
 		} else {
-			x, err := time.Parse(time.RFC3339, tStart)
+			x, err := t.gantt.parseDate(tStart)
 			if err != nil {
 				return fmt.Errorf(
-					`SetStart: "%s" is neither RFC3339 nor a valid Task ID`,
-					tStart)
+					`SetStart: "%s" is neither %s nor a valid Task ID`,
+					tStart, t.gantt.dateFormatName())
 			}
 			t.Start = &x
 		}
@@ -184,6 +345,47 @@ func (t *Task) SetStart(start interface{}) (err error) {
 	return nil
 }
 
+// SetAfter makes this Task start when all given Tasks have ended, e.g. for
+// a milestone gating a phase. The first Task is set as After, the others are
+// kept as further dependencies that are only used while After is set and
+// rendered as "after a b c", which makes mermaid start the Task at the latest
+// end. Start is unset. An error is returned and nothing is changed if no or
+// a nil Task is given.
+func (t *Task) SetAfter(deps ...*Task) (err error) {
+	if len(deps) == 0 {
+		return fmt.Errorf("SetAfter: no Tasks given")
+	}
+	for _, dep := range deps {
+		if dep == nil {
+			return fmt.Errorf("SetAfter: nil Task given")
+		}
+	}
+	t.After = deps[0]
+	t.afterMore = append([]*Task(nil), deps[1:]...)
+	t.Start = nil
+	return nil
+}
+
+// Dependencies returns the Tasks this Task starts after: After and the further
+// Tasks given to SetAfter. Without After an empty slice is returned.
+func (t *Task) Dependencies() (deps []*Task) {
+	deps = []*Task{}
+	if t.After != nil {
+		deps = append(deps, t.After)
+		deps = append(deps, t.afterMore...)
+	}
+	return deps
+}
+
+// dependencyIDs renders the IDs of the Dependencies separated by spaces.
+func (t *Task) dependencyIDs() string {
+	ids := []string{}
+	for _, dep := range t.Dependencies() {
+		ids = append(ids, dep.id)
+	}
+	return strings.Join(ids, " ")
+}
+
 // Helperfunction to deduplicate code.
 func (t *Task) setDurationFromTime(endTime *time.Time) (err error) {
 	if t.Start != nil && endTime != nil {
@@ -240,3 +442,108 @@ func (t *Task) SetDuration(duration interface{}) (err error) {
 	}
 	return nil
 }
+
+// SetBusinessDuration sets this Task's Duration to the given number of working
+// days. Since mermaid stretches Tasks over the days excluded by the Gantt's
+// Exclude settings, a Duration of n days ends after n non-excluded days. This
+// is used for the calendar end, too. An error is returned if days is negative,
+// the excludes leave no working day or this Task's start can't be resolved
+// from Start or After.
+func (t *Task) SetBusinessDuration(days int) (err error) {
+	if days < 0 {
+		return fmt.Errorf("SetBusinessDuration: negative number of days")
+	}
+	if err := t.gantt.validateExcludes(); err != nil {
+		return fmt.Errorf("SetBusinessDuration: %s", err)
+	}
+	if _, _, err := t.resolveDates(); err != nil {
+		return fmt.Errorf("SetBusinessDuration: %s", err)
+	}
+	duration := time.Duration(days) * 24 * time.Hour
+	t.Duration = &duration
+	return nil
+}
+
+// StartDate returns the resolved start of this Task, following After
+// references. An error is returned if no start can be resolved or After
+// references form a cycle.
+func (t *Task) StartDate() (start time.Time, err error) {
+	start, _, err = t.resolveDates()
+	if err != nil {
+		return start, fmt.Errorf("StartDate: %s", err)
+	}
+	return start, nil
+}
+
+// EndDate returns the resolved, exclusive end of this Task. Like mermaid does,
+// the Task is stretched over the days excluded by the Gantt's Exclude
+// settings, so with ExcludeWeekends a Task of 5 days starting on a Thursday
+// ends with the following Wednesday. An error is returned if no start can be
+// resolved or After references form a cycle.
+func (t *Task) EndDate() (end time.Time, err error) {
+	_, end, err = t.resolveDates()
+	if err != nil {
+		return end, fmt.Errorf("EndDate: %s", err)
+	}
+	return end, nil
+}
+
+// duration returns the absolute Duration of this Task, defaulting to one day
+// as rendered by String.
+func (t *Task) duration() time.Duration {
+	if t.Duration == nil {
+		return 24 * time.Hour
+	}
+	if *t.Duration < 0 {
+		return -*t.Duration
+	}
+	return *t.Duration
+}
+
+// resolveDates calculates the absolute start and end of this Task, following
+// its Dependencies (starting at the latest end) and stretching over excluded
+// days. An error is returned if neither Start nor After lead to an absolute
+// start or the Dependencies form a cycle.
+func (t *Task) resolveDates() (start, end time.Time, err error) {
+	return t.resolve(make(map[*Task]bool), make(resolvedDates))
+}
+
+// resolvedDates caches the start and end of already resolved Tasks, so Tasks
+// reached via several Dependencies (e.g. diamond shaped plans) are resolved
+// only once.
+type resolvedDates map[*Task][2]time.Time
+
+// resolve implements resolveDates, visiting holds the Tasks whose dates are
+// being resolved further up the dependency chain, resolved those already done.
+func (t *Task) resolve(visiting map[*Task]bool,
+	resolved resolvedDates) (start, end time.Time, err error) {
+	if dates, done := resolved[t]; done {
+		return dates[0], dates[1], nil
+	}
+	if t.Start != nil {
+		start = *t.Start
+	} else {
+		if t.After == nil {
+			return start, end, fmt.Errorf(
+				"no start can be resolved for Task %s", t.id)
+		}
+		if visiting[t] {
+			return start, end, fmt.Errorf(
+				"dependency cycle detected at Task %s", t.id)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		for i, dep := range t.Dependencies() {
+			_, depEnd, err := dep.resolve(visiting, resolved)
+			if err != nil {
+				return start, end, err
+			}
+			if i == 0 || depEnd.After(start) {
+				start = depEnd
+			}
+		}
+	}
+	end = t.gantt.extendOverExcludes(start, start.Add(t.duration()))
+	resolved[t] = [2]time.Time{start, end}
+	return start, end, nil
+}
@@ -1,11 +1,13 @@
 package gantt_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/Heiko-san/mermaidgen/gantt"
+	"github.com/StephenBrown2/mermaidgen/gantt"
 )
 
 // Accessing the readonly fields of a Task
@@ -82,6 +84,208 @@ func ExampleTask_settingValues() {
 	//A Task : crit, active, done, id8, 2019-06-20T09:15:30Z, 72000s
 }
 
+// Durations in working days
+func ExampleTask_SetBusinessDuration() {
+	g, _ := gantt.NewGantt()
+	// mermaid stretches Tasks over excluded days
+	g.ExcludeWeekends = true
+	g.ExcludeDates = []time.Time{time.Date(2019, 6, 26, 0, 0, 0, 0, time.UTC)}
+	t1, _ := g.AddTask("t1", "five working days")
+	t1.SetStart(time.Date(2019, 6, 20, 0, 0, 0, 0, time.UTC))
+	err := t1.SetBusinessDuration(5)
+	fmt.Println(err)
+	// without a start working days can't be laid out
+	t2, _ := g.AddTask("t2")
+	err = t2.SetBusinessDuration(5)
+	fmt.Println(err)
+	fmt.Print(g)
+	//Output:
+	//<nil>
+	//SetBusinessDuration: no start can be resolved for Task t2
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//excludes weekends, 2019-06-26T00:00:00Z
+	//five working days : t1, 2019-06-20T00:00:00Z, 432000s
+	//t2 : 1d
+}
+
+// Resolving the dates of a Task with and without excludes
+func ExampleTask_EndDate() {
+	g, _ := gantt.NewGantt()
+	g.DateFormat = gantt.DateFormatDate
+	// five days starting on a Thursday
+	t1, _ := g.AddTask("t1", "", "120h", "2019-06-20")
+	t2, _ := g.AddTask("t2", "", "24h", t1)
+	for _, excludeWeekends := range []bool{false, true} {
+		g.ExcludeWeekends = excludeWeekends
+		end1, _ := t1.EndDate()
+		start2, _ := t2.StartDate()
+		end2, _ := t2.EndDate()
+		fmt.Println(end1.Format("Mon 2006-01-02"), start2.Format("Mon 2006-01-02"),
+			end2.Format("Mon 2006-01-02"))
+	}
+	//Output:
+	//Tue 2019-06-25 Tue 2019-06-25 Wed 2019-06-26
+	//Thu 2019-06-27 Thu 2019-06-27 Fri 2019-06-28
+}
+
+func TestTask_setBusinessDurationErrors(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	t1, _ := g.AddTask("t1", "", "1h", time.Now())
+	assert(t, t1.SetBusinessDuration(-1) != nil)
+	g.ExcludeWeekends = true
+	g.ExcludeWeekdays = []time.Weekday{time.Monday, time.Tuesday,
+		time.Wednesday, time.Thursday, time.Friday}
+	assert(t, t1.SetBusinessDuration(1) != nil)
+	g.ExcludeWeekdays = g.ExcludeWeekdays[1:]
+	assert(t, t1.SetBusinessDuration(1) == nil)
+	// cyclic After references can't be resolved
+	t2, _ := g.AddTask("t2")
+	t3, _ := g.AddTask("t3", "", "1h", t2)
+	t2.After = t3
+	assert(t, t3.SetBusinessDuration(1) != nil)
+}
+
+// Task IDs are rendered whenever they are needed
+func ExampleTask_renderedIDs() {
+	g, _ := gantt.NewGantt()
+	g.AddTask("t1", "first", "1h", "2019-06-20T00:00:00Z")
+	// t2 has no start, but t3 depends on it, so its ID is rendered
+	t2, _ := g.AddTask("t2", "second")
+	t3, _ := g.AddTask("t3", "third")
+	t3.SetStart(t2)
+	// t4 is not referenced at all
+	g.AddTask("t4", "fourth")
+	fmt.Print(g)
+	// IDs can be rendered for all Tasks for stable output
+	g.AlwaysRenderIDs = true
+	fmt.Print(g)
+	//Output:
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//first : t1, 2019-06-20T00:00:00Z, 3600s
+	//second : t2, after t1, 1d
+	//third : t3, after t2, 1d
+	//fourth : 1d
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//first : t1, 2019-06-20T00:00:00Z, 3600s
+	//second : t2, after t1, 1d
+	//third : t3, after t2, 1d
+	//fourth : t4, after t3, 1d
+}
+
+// Tagging Tasks with custom classes
+func ExampleTask_AddClass() {
+	g, _ := gantt.NewGantt()
+	t1, _ := g.AddTask("t1", "backend work", "24h", "2019-06-20T00:00:00Z", false, true)
+	t1.AddClass("teamA")
+	t2, _ := g.AddTask("t2", "frontend work")
+	t2.AddClass("teamB")
+	g.SetClassDef("teamA", "fill: #bbf")
+	g.SetClassDef("teamB", "fill: #fbb")
+	// reserved tags can't be used as classes
+	fmt.Println(t2.AddClass("done"))
+	fmt.Print(g)
+	//Output:
+	//AddClass: invalid class name "done"
+	//%%{init: {"themeCSS":".teamA { fill: #bbf } .teamB { fill: #fbb }"}}%%
+	//gantt
+	//dateFormat YYYY-MM-DDTHH:mm:ssZ
+	//backend work : active, teamA, t1, 2019-06-20T00:00:00Z, 86400s
+	//frontend work : teamB, t2, after t1, 1d
+}
+
+// The canonical status and JSON encoding of Tasks
+func ExampleTask_Status() {
+	g, _ := gantt.NewGantt()
+	s, _ := g.AddSection("s1")
+	t1, _ := s.AddTask("t1", "build", "48h", "2019-06-20T00:00:00Z", true, false, true)
+	t2, _ := g.AddTask("t2", "", "1h", t1, true, true)
+	t3, _ := g.AddTask("t3")
+	fmt.Println(t1.Status(), t2.Status(), t3.Status())
+	data, _ := json.Marshal([]*gantt.Task{t1, t2})
+	fmt.Println(string(data))
+	//Output:
+	//done active planned
+	//[{"id":"t1","title":"build","section":"s1","start":"2019-06-20T00:00:00Z","duration":"48h0m0s","status":"done"},{"id":"t2","after":"t1","duration":"1h0m0s","status":"active"}]
+}
+
+func TestTask_SetAfter(t *testing.T) {
+	g, _ := gantt.NewGantt()
+	a, _ := g.AddTask("a", "", "24h", "2019-06-20T00:00:00Z")
+	b, _ := g.AddTask("b", "", "24h")
+	c, _ := g.AddTask("c", "", "24h", a)
+	if err := b.SetAfter(a, c); err != nil {
+		t.Fatal(err)
+	}
+	start, _ := b.StartDate()
+	assert(t, start.Equal(time.Date(2019, 6, 22, 0, 0, 0, 0, time.UTC)),
+		"expected b to start after c, got %s", start)
+	assert(t, strings.Contains(g.String(), "b : b, after a c, 86400s"),
+		"unexpected rendering %s", g)
+	// a cycle through a further dependency
+	c.SetAfter(a, b)
+	if _, err := b.EndDate(); err == nil {
+		t.Error("expected a cycle error")
+	}
+	err := g.ValidateDependencies()
+	assert(t, err != nil && strings.Contains(err.Error(), "cycle b -> c -> b"),
+		"unexpected error %v", err)
+	assert(t, b.SetAfter() != nil, "expected an error without Tasks")
+	c.SetStart(a)
+	assert(t, len(c.Dependencies()) == 1, "SetStart should replace Dependencies")
+}
+
+func TestTask_renderIDChain(t *testing.T) {
+	for _, mark := range []string{"class", "click"} {
+		g, _ := gantt.NewGantt()
+		g.AddTask("a", "A", "24h", "2019-06-20T00:00:00Z")
+		g.AddTask("b", "B", "24h")
+		s, _ := g.AddSection("s1")
+		s.AddTask("c", "C", "24h")
+		d, _ := s.AddTask("d", "D", "24h")
+		class := ""
+		if mark == "class" {
+			d.AddClass("foo")
+			class = "foo, "
+		} else {
+			g.AddClick("d", "https://example.com/d")
+		}
+		text := g.String()
+		for _, want := range []string{"B : b, after a, 86400s\n",
+			"C : c, after b, 86400s\n", "D : " + class + "d, after c, 86400s\n"} {
+			assert(t, strings.Contains(text, want), "%s: want %q in:\n%s", mark,
+				want, text)
+		}
+	}
+}
+
+func TestTask_resolveDiamonds(t *testing.T) {
+	// every Task is reached on 2^depth paths, which must not be followed
+	g, _ := gantt.NewGantt()
+	join, _ := g.AddTask("start", "", "24h", "2019-06-20T00:00:00Z")
+	const depth = 40
+	for i := 0; i < depth; i++ {
+		a, _ := g.AddTask(fmt.Sprintf("a%d", i), "", "24h", join)
+		b, _ := g.AddTask(fmt.Sprintf("b%d", i), "", "48h", join)
+		join, _ = g.AddTask(fmt.Sprintf("j%d", i), "", "24h")
+		if err := join.SetAfter(a, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, end, err := g.Span()
+	assert(t, err == nil, "unexpected error %v", err)
+	want := time.Date(2019, 6, 21+3*depth, 0, 0, 0, 0, time.UTC)
+	assert(t, end.Equal(want), "expected the end %s, got %s", want, end)
+	path, err := g.CriticalPath()
+	assert(t, err == nil && len(path) == 2*depth+1,
+		"unexpected critical path of %d Tasks, error %v", len(path), err)
+	critical, err := g.CriticalSet()
+	assert(t, err == nil && critical[join] && !critical[g.GetTask("a0")],
+		"unexpected critical set, error %v", err)
+}
+
 func assert(t *testing.T, condition bool, msg ...interface{}) {
 	if !condition {
 		if len(msg) > 0 {
@@ -138,6 +342,11 @@ func TestTask_copyFields(t *testing.T) {
 	assert(t, t1.ID() != t2.ID())
 	assert(t, t1.Section() != t2.Section())
 
+	t1.AddClass("c1")
+	t2.CopyFields(t1)
+	t1.AddClass("c2")
+	assert(t, len(t2.Classes()) == 1 && t2.Classes()[0] == "c1")
+
 	t1.SetStart(time.Now())
 	t2.CopyFields(t1)
 
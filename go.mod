@@ -1,3 +1,3 @@
 module github.com/StephenBrown2/mermaidgen
 
-go 1.12
+go 1.18